@@ -0,0 +1,321 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+* License, v. 2.0. If a copy of the MPL was not distributed with this
+* file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/go-gl/gl/v2.1/gl"
+)
+
+// Layout read from a level file: obstacles, gravity wells and portals.
+// The host loads it and shares it, so clients don't need the file.
+//
+// Every line of the file is a comment starting with #, or one of
+//
+//	obstacle x,y x,y x,y ...
+//	well x y strength core [orbit orbitSpeed]
+//	portal x1 y1 angle1 x2 y2 angle2 length
+//
+// with positions as fractions of the field size, so levels fit any
+// window.
+type Level struct {
+	Obstacles [][]Vector
+	Wells     []wellSpec
+	Portals   []portalSpec
+}
+
+func LoadLevel(path string) (*Level, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	level := new(Level)
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		switch fields[0] {
+		case "obstacle":
+			var points []Vector
+			for _, field := range fields[1:] {
+				var p Vector
+				if _, err := fmt.Sscanf(field, "%g,%g", &p.X, &p.Y); err != nil {
+					return nil, fmt.Errorf("%s:%d: bad point %q", path, line, field)
+				}
+				points = append(points, p)
+			}
+			if len(points) < 3 {
+				return nil, fmt.Errorf("%s:%d: an obstacle needs at least 3 points", path, line)
+			}
+			level.Obstacles = append(level.Obstacles, points)
+		case "well", "portal":
+			var values []float64
+			for _, field := range fields[1:] {
+				v, err := strconv.ParseFloat(field, 64)
+				if err != nil {
+					return nil, fmt.Errorf("%s:%d: bad number %q", path, line, field)
+				}
+				values = append(values, v)
+			}
+			if fields[0] == "portal" {
+				if len(values) != 7 {
+					return nil, fmt.Errorf("%s:%d: a portal needs x, y and angle of both ends and a length", path, line)
+				}
+				level.Portals = append(level.Portals, portalSpec{values[0], values[1], values[2], values[3], values[4], values[5], values[6]})
+				continue
+			}
+			if len(values) != 4 && len(values) != 6 {
+				return nil, fmt.Errorf("%s:%d: a well needs x, y, strength, core and optionally orbit and orbit speed", path, line)
+			}
+			values = append(values, 0, 0)
+			level.Wells = append(level.Wells, wellSpec{values[0], values[1], values[2], values[3], values[4], values[5]})
+		default:
+			return nil, fmt.Errorf("%s:%d: unknown item %q", path, line, fields[0])
+		}
+	}
+	return level, scanner.Err()
+}
+
+// Keeps what the host placed for the level in sync with paxos. The
+// host sets up the level and shares where its wells and portals are;
+// everyone else copies them.
+func updateLevelObjects() {
+	syncLevelObjects("gravity_wells", &wells)
+	syncLevelObjects("portals", &portals)
+}
+
+// Shares objects, a pointer to a slice of level objects, under key
+// on the host, or copies the host's into it everywhere else.
+func syncLevelObjects(key string, objects interface{}) {
+	if !isClient {
+		gameNode.ShareLevelObjects(key, objects)
+		return
+	}
+	gameNode.GetLevelObjects(key, objects)
+}
+
+// Solid polygon in the field. Ships and asteroids bounce off it,
+// projectiles break on it.
+type Obstacle struct {
+	Entity
+}
+
+// Obstacle with the given corners, as fractions of the field size.
+func NewObstacle(points []Vector) *Obstacle {
+	var shape Polygon
+	for _, p := range points {
+		shape.Vectors = append(shape.Vectors, Vector{p.X * gameWidth, p.Y * gameHeight})
+		shape.Colors = append(shape.Colors, Color{0.5, 0.5, 0.6})
+	}
+	c := recenter(shape.Vectors)
+	return &Obstacle{*NewEntity(shape, c.X, c.Y, 0, 0, 0, 0, 0, 0)}
+}
+
+// Obstacles of the level the host loaded, if any.
+func levelObstacles() []*Obstacle {
+	var obstacles []*Obstacle
+	if gameNode.Level != nil {
+		for _, points := range gameNode.Level.Obstacles {
+			obstacles = append(obstacles, NewObstacle(points))
+		}
+	}
+	return obstacles
+}
+
+// Closest point to x, y on the obstacle's outline, both relative to
+// the obstacle's center.
+func (obstacle *Obstacle) closestPoint(x, y float64) (float64, float64) {
+	bestX, bestY, best := 0.0, 0.0, math.Inf(1)
+	n := len(obstacle.Shape.Vectors)
+	for v := 0; v < n; v++ {
+		a, b := obstacle.Shape.Vectors[v], obstacle.Shape.Vectors[(v+1)%n]
+		ax, ay := a.X, a.Y
+		ex, ey := b.X-a.X, b.Y-a.Y
+		t := 0.0
+		if length := ex*ex + ey*ey; length > 0 {
+			t = math.Max(0, math.Min(1, ((x-ax)*ex+(y-ay)*ey)/length))
+		}
+		px, py := ax+t*ex, ay+t*ey
+		if d := math.Hypot(x-px, y-py); d < best {
+			bestX, bestY, best = px, py, d
+		}
+	}
+	return bestX, bestY
+}
+
+// Whether x, y, relative to the obstacle's center, lies inside it.
+func (obstacle *Obstacle) contains(x, y float64) bool {
+	inside := false
+	n := len(obstacle.Shape.Vectors)
+	for v, w := 0, n-1; v < n; w, v = v, v+1 {
+		a, b := obstacle.Shape.Vectors[v], obstacle.Shape.Vectors[w]
+		if (a.Y > y) != (b.Y > y) {
+			crossX := a.X + (y-a.Y)/(b.Y-a.Y)*(b.X-a.X)
+			if x < crossX {
+				inside = !inside
+			}
+		}
+	}
+	return inside
+}
+
+// Reflects ent's velocity off the obstacle's nearest edge if it is
+// moving into it.
+func (obstacle *Obstacle) Bounce(ent *Entity) {
+	x, y := wrappedOffset(obstacle.PosX, obstacle.PosY, ent.PosX, ent.PosY)
+	px, py := obstacle.closestPoint(x, y)
+	nx, ny := x-px, y-py
+	d := math.Hypot(nx, ny)
+	if d == 0 {
+		// center on the outline, push away from the middle instead
+		nx, ny = x, y
+		d = math.Hypot(nx, ny)
+		if d == 0 {
+			return
+		}
+	}
+	nx, ny = nx/d, ny/d
+	if obstacle.contains(x, y) {
+		nx, ny = -nx, -ny
+	}
+	if dot := ent.VelocityX*nx + ent.VelocityY*ny; dot < 0 {
+		ent.VelocityX -= 2 * dot * nx
+		ent.VelocityY -= 2 * dot * ny
+	}
+}
+
+func (obstacle *Obstacle) Draw() {
+	gl.Begin(gl.LINE_LOOP)
+	for v := range obstacle.Shape.Vectors {
+		obstacle.Color3d(obstacle.Shape.Colors[v])
+		obstacle.GlVertex2d(obstacle.Shape.Vectors[v])
+	}
+	gl.End()
+}
+
+// Whether ent's center has come within margin of the arena's walls,
+// and the side it did so by.
+func outOfBounds(ent *Entity, margin float64) (bool, float64, float64) {
+	nx, ny := 0.0, 0.0
+	if ent.PosX < margin {
+		nx = 1
+	} else if ent.PosX > gameWidth-margin {
+		nx = -1
+	}
+	if ent.PosY < margin {
+		ny = 1
+	} else if ent.PosY > gameHeight-margin {
+		ny = -1
+	}
+	return nx != 0 || ny != 0, nx, ny
+}
+
+// Keeps all of ent inside the arena, bouncing it off the wall it hit.
+func bounceOffWalls(ent *Entity) {
+	margin := math.Min(ent.Radius(), math.Min(gameWidth, gameHeight)/2)
+	out, nx, ny := outOfBounds(ent, margin)
+	if !out {
+		return
+	}
+	if nx != 0 && ent.VelocityX*nx < 0 {
+		ent.VelocityX = -ent.VelocityX
+	}
+	if ny != 0 && ent.VelocityY*ny < 0 {
+		ent.VelocityY = -ent.VelocityY
+	}
+	ent.PosX = math.Max(margin, math.Min(gameWidth-margin, ent.PosX))
+	ent.PosY = math.Max(margin, math.Min(gameHeight-margin, ent.PosY))
+}
+
+// Walls and obstacles of a bounded arena: ships, asteroids, saucers
+// and the mothership bounce, projectiles break.
+func arenaHitDetection() {
+	if mode.Arena {
+		for _, ships := range shipMap {
+			bounceOffWalls(&ships.Entity)
+		}
+		for _, asteroid := range asteroids {
+			bounceOffWalls(&asteroid.Entity)
+		}
+		for _, saucer := range saucers {
+			bounceOffWalls(&saucer.Entity)
+		}
+		if boss != nil {
+			bounceOffWalls(&boss.Entity)
+		}
+		for _, bullet := range bullets {
+			if out, _, _ := outOfBounds(&bullet.Entity, 0); out && bullet.IsAlive() {
+				bullet.Destroy()
+			}
+		}
+		for _, torpedo := range torpedos {
+			if out, _, _ := outOfBounds(&torpedo.Entity, 0); out && torpedo.IsAlive() {
+				torpedo.Destroy()
+			}
+		}
+	}
+
+	for _, obstacle := range obstacles {
+		for _, ships := range shipMap {
+			if ships.IsAlive() && mayCollide(&obstacle.Entity, &ships.Entity) && IsColliding(&obstacle.Entity, &ships.Entity) {
+				obstacle.Bounce(&ships.Entity)
+			}
+		}
+		for _, asteroid := range asteroids {
+			if asteroid.IsAlive() && mayCollide(&obstacle.Entity, &asteroid.Entity) && IsColliding(&obstacle.Entity, &asteroid.Entity) {
+				obstacle.Bounce(&asteroid.Entity)
+			}
+		}
+		for _, bullet := range bullets {
+			if bullet.IsAlive() && mayCollide(&obstacle.Entity, &bullet.Entity) && IsColliding(&obstacle.Entity, &bullet.Entity) {
+				bullet.Destroy()
+			}
+		}
+		for _, torpedo := range torpedos {
+			if torpedo.IsAlive() && mayCollide(&obstacle.Entity, &torpedo.Entity) && IsColliding(&obstacle.Entity, &torpedo.Entity) {
+				torpedo.Destroy()
+			}
+		}
+	}
+}
+
+// Distance from x, y in direction angle to the arena's walls.
+func wallDistance(x, y, angle float64) float64 {
+	rad := angle * math.Pi / 180
+	dx, dy := math.Sin(rad), math.Cos(rad)
+	d := math.Inf(1)
+	if dx > 0 {
+		d = math.Min(d, (gameWidth-x)/dx)
+	} else if dx < 0 {
+		d = math.Min(d, -x/dx)
+	}
+	if dy > 0 {
+		d = math.Min(d, (gameHeight-y)/dy)
+	} else if dy < 0 {
+		d = math.Min(d, -y/dy)
+	}
+	return math.Max(0, d)
+}
+
+// Draws the arena's walls.
+func drawWalls() {
+	gl.Begin(gl.LINE_LOOP)
+	gl.Color3d(Colorize(0.6), Colorize(0.6), Colorize(0.7))
+	gl.Vertex2d(0, 0)
+	gl.Vertex2d(gameWidth, 0)
+	gl.Vertex2d(gameWidth, gameHeight)
+	gl.Vertex2d(0, gameHeight)
+	gl.End()
+}
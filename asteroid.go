@@ -4,52 +4,75 @@
 
 package main
 
+import (
+	"math"
+	"math/rand"
+)
+
 type Asteroid struct {
 	Entity
 	SizeRatio float64
-	Lives     int
+	Lives     int // Times it still splits when destroyed.
 	Id        int
+	Health    int
+	impact    Impact    // Last hit, which decides how it splits.
+	Seed      int64     // Decides the shape, so every player sees the same rock.
+	Fracture  *Fracture // How it was cut out of a bigger rock, nil if whole.
+	Material  Material
+	hitBy     int  // Player who hit it last, noOwner if nobody has.
+	shard     bool // Shared as a shard, whether or not its Fracture is known yet.
 }
 
-func NewAsteroid(x, y, angle, turnrate, vX, vY, size float64, lives int) *Asteroid {
-	shape := Polygon{
-		[]Vector{
-			Vector{0 * size, 5.0 * size},
-			Vector{5.0 * size, 2.0 * size},
-			Vector{4.0 * size, -3.0 * size},
-			Vector{1.0 * size, -3.0 * size},
-			Vector{-1.0 * size, -5.0 * size},
-			Vector{-3.0 * size, -5.0 * size},
-			Vector{-4.0 * size, -1.0 * size},
-			Vector{-6.0 * size, 2.0 * size},
-		},
-		[]Color{
-			Color{1, 1, 1},
-			Color{1, 0.9, 0.9},
-			Color{0.8, 0.8, 0.9},
-			Color{0.8, 0.8, 0.8},
-			Color{0.9, 0.8, 0.8},
-			Color{0.9, 0.9, 0.9},
-			Color{0.9, 0.9, 0.9},
-			Color{1, 1, 0.9},
-		},
-	}
+func NewAsteroid(x, y, angle, turnrate, vX, vY, size float64, lives int, seed int64, material Material) *Asteroid {
 	id := NextAsteroidId()
-	return &Asteroid{*NewEntity(shape, x, y, angle, turnrate, vX, vY, 0, 5), size, lives, id}
+	asteroid := &Asteroid{*NewEntity(asteroidShape(seed, size), x, y, angle, turnrate, vX, vY, 0, 5), size, lives, id, asteroidHealth(size, material), Impact{x, y, 0, 0}, seed, nil, material, noOwner, false}
+	asteroid.paint()
+	return asteroid
+}
+
+// Outline of a rock of the given size, generated from seed: a ring of
+// 7 to 11 vertices at jittered angles and distances, some of them
+// dented in. It reaches at most 6*size from its center. The colors
+// come from the asteroid's material.
+func asteroidShape(seed int64, size float64) Polygon {
+	r := rand.New(rand.NewSource(seed))
+	n := 7 + r.Intn(5)
+	step := 2 * math.Pi / float64(n)
+
+	var shape Polygon
+	for i := 0; i < n; i++ {
+		rad := (float64(i) + (r.Float64()-0.5)*0.6) * step
+		radius := 5 * size * (0.7 + r.Float64()*0.5)
+		if r.Float64() < 0.2 {
+			radius *= 0.6
+		}
+		shape.Vectors = append(shape.Vectors, Vector{radius * math.Sin(rad), radius * math.Cos(rad)})
+	}
+	return shape
 }
 
 func (ast *Asteroid) Destroy() {
-	addScore(5 - ast.Lives)
+	material := materialTypes[ast.Material]
+	addScore(material.Score)
 	ast.Entity.Destroy()
-	if ast.Lives > 0 {
-		ast.CreateChild()
-		ast.CreateChild()
+	// a rock that won't cut splits the usual way
+	if ast.Lives > 0 && (!mode.Fracture || !ast.Shatter()) {
+		for _, velocity := range splitRule().Split(ast, material.Pieces, material.SplitSpeed) {
+			ast.CreateChild(velocity)
+		}
 	}
+	dropPowerUp(ast.Id, ast.PosX, ast.PosY, material.DropChance)
 	explosions = append(explosions, NewExplosion(ast.PosX, ast.PosY, ast.SizeRatio))
+	if material.Explodes {
+		bigExplosions = append(bigExplosions, NewBigExplosion(ast.PosX, ast.PosY, ast.SizeRatio/2, ast.hitBy))
+	}
 }
 
-func (ast *Asteroid) CreateChild() {
-	asteroid := NewAsteroid(ast.PosX, ast.PosY, rng.Float64()*360, rng.Float64()/10, (rng.Float64()-0.5)/4, (rng.Float64()-0.5)/4, ast.SizeRatio/1.5, ast.Lives-1)
+// How much smaller than its parent a child asteroid is.
+const childShrink = 1.5
+
+func (ast *Asteroid) CreateChild(velocity Vector) {
+	asteroid := NewAsteroid(ast.PosX, ast.PosY, rng.Float64()*360, rng.Float64()/10, velocity.X, velocity.Y, ast.SizeRatio/childShrink, ast.Lives-1, rng.Int63(), ast.Material)
 	if rng.Float64() > 0.5 {
 		asteroid.RotateRight(true)
 	} else {
@@ -60,17 +83,11 @@ func (ast *Asteroid) CreateChild() {
 
 func CreateAsteroid(size float64, lives int) {
 
-	// avoid creating asteroid too close to ship/player starting position..
-	var x float64 = 0
-	if rng.Float64() > 0.5 {
-		x = gameWidth / 3 * 2
-	}
-	var y float64 = 0
-	if rng.Float64() > 0.5 {
-		y = gameHeight / 3 * 2
-	}
+	// avoid creating asteroid too close to ships and other asteroids..
+	// a shape of this size reaches out 6*size from its center.
+	x, y, _ := FindSpawnPoint(mode.SpawnRadius + 6*size)
 
-	asteroid := NewAsteroid((rng.Float64()*gameWidth/3)+x, (rng.Float64()*gameHeight/3)+y, rng.Float64()*360, rng.Float64()/10, (rng.Float64()-0.5)/2, (rng.Float64()-0.5)/2, size, lives)
+	asteroid := NewAsteroid(x, y, rng.Float64()*360, rng.Float64()/10, (rng.Float64()-0.5)/2, (rng.Float64()-0.5)/2, size, lives, rng.Int63(), randomMaterial())
 	if rng.Float64() > 0.5 {
 		asteroid.RotateRight(true)
 	} else {
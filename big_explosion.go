@@ -10,9 +10,11 @@ type BigExplosion struct {
 	Entity
 	MaxLifetime float64
 	Size        float64
+	Owner       int
+	hit         map[*Entity]bool // What it already did damage to.
 }
 
-func NewBigExplosion(x, y, size float64) *BigExplosion {
+func NewBigExplosion(x, y, size float64, owner int) *BigExplosion {
 	shape := Polygon{
 		[]Vector{
 			Vector{-1 * size, 2 * size},
@@ -36,7 +38,7 @@ func NewBigExplosion(x, y, size float64) *BigExplosion {
 		},
 	}
 
-	explosion := &BigExplosion{*NewEntity(shape, x, y, 0, 0, 0, 0, 0, 0), 1, size}
+	explosion := &BigExplosion{*NewEntity(shape, x, y, 0, 0, 0, 0, 0, 0), 1, size, owner, make(map[*Entity]bool)}
 	return explosion
 }
 
@@ -0,0 +1,396 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+* License, v. 2.0. If a copy of the MPL was not distributed with this
+* file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package main
+
+import (
+	"math"
+
+	glfw "github.com/go-gl/glfw3/v3.0/glfw"
+)
+
+// Every bossLevelEvery-th level brings a mothership.
+const bossLevelEvery = 3
+
+// Points every player gets for destroying the first mothership. Each
+// one after that is worth bossBonus more than the last.
+const bossBonus = 1000
+
+// Level the players are on, counting from 1. N advances it on the
+// host; clients follow in updateLevel.
+var level = 1
+
+func isBossLevel() bool {
+	return level%bossLevelEvery == 0
+}
+
+// Follows the host to the level it is playing.
+func updateLevel() {
+	if !isClient {
+		return
+	}
+	if shared, err := gameNode.GetGameLevel(); err == nil {
+		level = shared
+	}
+}
+
+// Huge mothership. Its hull can't be hurt; it goes down once all of
+// its weak points are shot out. It gets angrier as they go: it turns
+// faster, fires wider volleys and, at the end, launches saucers. The
+// host runs it; everyone else mirrors it through paxos and tells the
+// host how much damage they did.
+type Boss struct {
+	Entity
+	Id         int
+	Level      int
+	WeakPoints []*WeakPoint
+	Phase      int     // 0 to 2, rising as it loses health.
+	Shots      int     // Volleys fired so far.
+	AimAngle   float64 // Direction of the last volley.
+	nextShot   float64
+	nextTurn   float64
+	nextSaucer float64
+}
+
+// Spot on a mothership's hull that takes damage.
+type WeakPoint struct {
+	Entity
+	Offset    Vector // Position on the hull, in the boss's own frame.
+	Health    int
+	MaxHealth int
+}
+
+func NewBoss(id, level int, x, y float64) *Boss {
+	var shape Polygon
+	for i := 0; i < 12; i++ {
+		radius := 34.0
+		if i%2 == 1 {
+			radius = 28
+		}
+		rad := float64(i) * math.Pi / 6
+		shape.Vectors = append(shape.Vectors, Vector{radius * math.Sin(rad), radius * math.Cos(rad)})
+		shape.Colors = append(shape.Colors, Color{0.6, 0.5 + 0.1*float64(i%3), 0.8})
+	}
+
+	boss := &Boss{*NewEntity(shape, x, y, 0, 0.05, 0, 0, 0, 5), id, level, nil, 0, 0, 0, 0, 0, 0}
+	for i := 0; i < 4; i++ {
+		rad := (45 + float64(i)*90) * math.Pi / 180
+		boss.WeakPoints = append(boss.WeakPoints, NewWeakPoint(Vector{36 * math.Sin(rad), 36 * math.Cos(rad)}, 6+2*level))
+	}
+	boss.RotateRight(true)
+	boss.placeWeakPoints()
+
+	now := glfw.GetTime()
+	boss.nextShot = now + 2
+	boss.nextTurn = now
+	boss.nextSaucer = now + 8
+	return boss
+}
+
+func NewWeakPoint(offset Vector, health int) *WeakPoint {
+	shape := Polygon{
+		[]Vector{
+			Vector{0, 6},
+			Vector{6, 0},
+			Vector{0, -6},
+			Vector{-6, 0},
+		},
+		[]Color{
+			Color{1, 0.2, 0.2},
+			Color{1, 0.5, 0.2},
+			Color{1, 0.2, 0.2},
+			Color{1, 0.5, 0.2},
+		},
+	}
+	return &WeakPoint{*NewEntity(shape, 0, 0, 0, 0, 0, 0, 0, 0), offset, health, health}
+}
+
+// Host launches the mothership of the current level at the edge of
+// the field, away from the players.
+func spawnBoss() {
+	x, y, _ := FindSpawnPoint(mode.SpawnRadius+40, Vector{gameWidth / 2, gameHeight - 50})
+	// the id comes from the host's saucer counter, no other player
+	// creates bosses
+	boss = NewBoss(NextSaucerId(), level, x, y)
+	bossDamage = make([]int, len(boss.WeakPoints))
+}
+
+// Moves the weak points along with the hull.
+func (boss *Boss) placeWeakPoints() {
+	for _, point := range boss.WeakPoints {
+		x, y := point.Offset.Rotate(boss.Angle)
+		point.PosX = boss.PosX + x
+		point.PosY = boss.PosY + y
+		point.Angle = boss.Angle
+	}
+}
+
+func (boss *Boss) Health() int {
+	health := 0
+	for _, point := range boss.WeakPoints {
+		health += int(math.Max(0, float64(point.Health)))
+	}
+	return health
+}
+
+func (boss *Boss) MaxHealth() int {
+	health := 0
+	for _, point := range boss.WeakPoints {
+		health += point.MaxHealth
+	}
+	return health
+}
+
+func (point *WeakPoint) IsAlive() bool {
+	return point.Health > 0 && point.Entity.IsAlive()
+}
+
+// Flies slowly after the nearest ship, turns and fires volleys at it.
+// Only called on the host.
+func (boss *Boss) Think() {
+	if paused || !boss.IsAlive() {
+		return
+	}
+	now := glfw.GetTime()
+
+	boss.Phase = 2 - 3*boss.Health()/(boss.MaxHealth()+1)
+	boss.TurnRate = 0.05 * float64(boss.Phase+1)
+
+	target := boss.nearestShip()
+	if now >= boss.nextTurn && target != nil {
+		heading := headingTo(boss.PosX, boss.PosY, target.PosX, target.PosY)
+		rad := heading * math.Pi / 180
+		boss.VelocityX = 0.03 * math.Sin(rad)
+		boss.VelocityY = 0.03 * math.Cos(rad)
+		boss.nextTurn = now + 3
+	}
+
+	if now >= boss.nextShot && target != nil {
+		boss.nextShot = now + 1.2 - 0.3*float64(boss.Phase)
+		boss.AimAngle = headingTo(boss.PosX, boss.PosY, target.PosX, target.PosY)
+		boss.fire()
+	}
+
+	if boss.Phase == 2 && now >= boss.nextSaucer {
+		boss.nextSaucer = now + 8
+		CreateSaucer()
+	}
+}
+
+func (boss *Boss) nearestShip() *Ship {
+	var nearest *Ship
+	best := math.Inf(1)
+	for _, ships := range shipMap {
+		if !ships.IsAlive() {
+			continue
+		}
+		d := wrappedDistance(boss.PosX, boss.PosY, ships.PosX, ships.PosY)
+		if d < best {
+			nearest, best = ships, d
+		}
+	}
+	return nearest
+}
+
+// Directions of the bullets of one volley, wider in later phases.
+func (boss *Boss) spread() []float64 {
+	switch boss.Phase {
+	case 0:
+		return []float64{0}
+	case 1:
+		return []float64{-15, 0, 15}
+	}
+	return []float64{-30, -15, 0, 15, 30}
+}
+
+// Fires a volley around AimAngle.
+func (boss *Boss) fire() {
+	for _, offset := range boss.spread() {
+		rad := (boss.AimAngle + offset) * math.Pi / 180
+		bullet := NewBullet(
+			boss.PosX+math.Sin(rad)*40,
+			boss.PosY+math.Cos(rad)*40,
+			saucerBulletSpeed*math.Sin(rad),
+			saucerBulletSpeed*math.Cos(rad),
+			saucerOwner,
+		)
+		bullets = append(bullets, bullet)
+	}
+	boss.Shots += 1
+}
+
+func (boss *Boss) Update() {
+	if paused {
+		timediff := (glfw.GetTime() - boss.Entity.lastUpdatedTime)
+		boss.nextShot += timediff
+		boss.nextTurn += timediff
+		boss.nextSaucer += timediff
+	}
+	boss.Entity.Update()
+	boss.placeWeakPoints()
+}
+
+func (boss *Boss) Draw() {
+	if !boss.IsAlive() {
+		return
+	}
+	boss.Entity.Draw(false)
+	for _, point := range boss.WeakPoints {
+		if point.IsAlive() {
+			point.Entity.Draw(false)
+		}
+	}
+}
+
+// Takes damage off weak point i of the boss, hit by player owner.
+func (boss *Boss) Damage(i, amount, owner int) {
+	point := boss.WeakPoints[i]
+	if !boss.IsAlive() || !point.IsAlive() {
+		return
+	}
+	recordDamage(owner, amount, point.PosX, point.PosY)
+	point.Health -= amount
+	if owner == PlayerId {
+		bossDamage[i] += amount
+		if isClient {
+			gameNode.ShareBossDamage(boss.Id, bossDamage)
+		}
+	}
+	if !point.IsAlive() {
+		explosions = append(explosions, NewExplosion(point.PosX, point.PosY, 6))
+	}
+	if boss.Health() == 0 {
+		boss.Destroy()
+	}
+}
+
+// Blows the mothership up and gives every player the bonus.
+func (boss *Boss) Destroy() {
+	if !boss.Entity.IsAlive() {
+		return
+	}
+	boss.Entity.Destroy()
+	for i := 0; i < 5; i++ {
+		explosions = append(explosions, NewExplosion(boss.PosX+(rng.Float64()-0.5)*50, boss.PosY+(rng.Float64()-0.5)*50, 15))
+	}
+	addScore(bossBonus * boss.Level / bossLevelEvery)
+}
+
+// Keeps the boss in sync with paxos. The host works out its health
+// from the damage every player reported, runs it and shares it;
+// clients copy it, keep the lower health of their own view and the
+// host's, and replay its volleys.
+func updateBoss() {
+	if !isClient {
+		if boss == nil {
+			gameNode.ShareBoss(nil)
+			return
+		}
+		others := gameNode.GetBossDamage(boss.Id)
+		for i, point := range boss.WeakPoints {
+			point.Health = point.MaxHealth - bossDamage[i]
+			if i < len(others) {
+				point.Health -= others[i]
+			}
+		}
+		if boss.Health() == 0 {
+			boss.Destroy()
+		}
+		boss.Think()
+		gameNode.ShareBoss(boss)
+		return
+	}
+
+	shared := gameNode.GetBoss()
+	if shared == nil {
+		boss = nil
+		return
+	}
+	if boss == nil || boss.Id != shared.Id {
+		boss = NewBoss(shared.Id, shared.Level, shared.PosX, shared.PosY)
+		boss.Shots = shared.Shots
+		bossDamage = make([]int, len(boss.WeakPoints))
+	}
+	boss.PosX = shared.PosX
+	boss.PosY = shared.PosY
+	boss.Angle = shared.Angle
+	boss.VelocityX = shared.VelocityX
+	boss.VelocityY = shared.VelocityY
+	boss.Phase = shared.Phase
+	boss.TurnRate = 0.05 * float64(boss.Phase+1)
+	boss.AimAngle = shared.AimAngle
+	for i, point := range boss.WeakPoints {
+		if i < len(shared.WeakPoints) && shared.WeakPoints[i].Health < point.Health {
+			point.Health = shared.WeakPoints[i].Health
+		}
+	}
+	boss.placeWeakPoints()
+	for boss.IsAlive() && boss.Shots < shared.Shots {
+		boss.fire()
+	}
+	if !shared.IsAlive() || boss.Health() == 0 {
+		boss.Destroy()
+	}
+}
+
+// Hits on the boss: weak points take damage, the hull just stops
+// projectiles and hurts ships flying into it.
+func bossHitDetection() {
+	if boss == nil || !boss.IsAlive() {
+		return
+	}
+	for _, bullet := range bullets {
+		if !bullet.IsAlive() || bullet.Owner == saucerOwner {
+			continue
+		}
+		for i, point := range boss.WeakPoints {
+			if bullet.IsAlive() && point.IsAlive() && IsColliding(&point.Entity, &bullet.Entity) {
+				bullet.Destroy()
+				boss.Damage(i, bulletDamage, bullet.Owner)
+			}
+		}
+		if bullet.IsAlive() && IsColliding(&boss.Entity, &bullet.Entity) {
+			bullet.Destroy()
+		}
+	}
+	for _, torpedo := range torpedos {
+		for i, point := range boss.WeakPoints {
+			if torpedo.IsAlive() && point.IsAlive() && IsColliding(&point.Entity, &torpedo.Entity) {
+				torpedo.Destroy()
+				boss.Damage(i, torpedoDamage, torpedo.Owner)
+			}
+		}
+		if torpedo.IsAlive() && IsColliding(&boss.Entity, &torpedo.Entity) {
+			torpedo.Destroy()
+		}
+	}
+	for _, bigExplosion := range bigExplosions {
+		if bigExplosion.Owner == saucerOwner || bigExplosion.Owner == noOwner {
+			continue
+		}
+		for i, point := range boss.WeakPoints {
+			if point.IsAlive() {
+				if damage := bigExplosion.DamageTo(&point.Entity); damage > 0 {
+					boss.Damage(i, damage, bigExplosion.Owner)
+				}
+			}
+		}
+	}
+	for _, ships := range shipMap {
+		if boss.IsAlive() && ships.CanBeHit() && IsColliding(&boss.Entity, &ships.Entity) {
+			ships.Damage(collisionDamage, saucerOwner)
+		}
+	}
+}
+
+// Shows the boss's health at the top of the screen.
+func drawBossBar() {
+	if boss == nil || !boss.IsAlive() {
+		return
+	}
+	width := 160.0
+	x := screenWidth/2 - width/2
+	DrawString(x, fieldSize-20, 1, Color{0.8, 0.6, 1}, "mothership")
+	drawBar(x, fieldSize-30, width, float64(boss.Health())/float64(boss.MaxHealth()), Color{1, 0.3, 0.3})
+}
@@ -4,14 +4,19 @@
 
 package main
 
-import glfw "github.com/go-gl/glfw3/v3.0/glfw"
+import (
+	"math"
+
+	glfw "github.com/go-gl/glfw3/v3.0/glfw"
+)
 
 type Bullet struct {
 	Entity
 	MaxLifetime float64
+	Owner       int
 }
 
-func NewBullet(x, y, vX, vY float64) *Bullet {
+func NewBullet(x, y, vX, vY float64, owner int) *Bullet {
 	shape := Polygon{
 		[]Vector{
 			Vector{0, 1},
@@ -24,7 +29,7 @@ func NewBullet(x, y, vX, vY float64) *Bullet {
 			Color{1, 0, 0},
 		},
 	}
-	bullet := &Bullet{*NewEntity(shape, x, y, 0, 2, vX, vY, 0, 5), 1.8}
+	bullet := &Bullet{*NewEntity(shape, x, y, 0, 2, vX, vY, 0, 5), 1.8, owner}
 	if rng.Float64() > 0.5 {
 		bullet.RotateRight(true)
 	} else {
@@ -47,3 +52,48 @@ func (bullet *Bullet) IsAlive() bool {
 	}
 	return bullet.Entity.IsAlive()
 }
+
+// The ship's standard gun, firing bullets from its nose.
+type Blaster struct {
+	Magazine
+}
+
+func init() {
+	RegisterWeapon(0, func() Weapon { return &Blaster{NewMagazine(0, 0)} })
+}
+
+func (blaster *Blaster) Name() string {
+	return "blaster"
+}
+
+func (blaster *Blaster) Cooldown(ship *Ship) float64 {
+	if ship.HasEffect(PowerUpRapidFire) {
+		return 0.1
+	}
+	return 0.2
+}
+
+func (blaster *Blaster) Spread(ship *Ship) []float64 {
+	if ship.HasEffect(PowerUpSpreadShot) {
+		return []float64{0, -15, 15}
+	}
+	return []float64{0}
+}
+
+func (blaster *Blaster) Launch(ship *Ship, angle float64) {
+	var rad float64 = ((angle) * math.Pi) / 180
+	x, y := RotateVector(&Vector{0, 5}, angle)
+
+	bullet := NewBullet(
+		ship.PosX+x,
+		ship.PosY+y,
+		ship.MaxVelocity*math.Sin(rad)*2,
+		ship.MaxVelocity*math.Cos(rad)*2,
+		ship.PlayerId,
+	)
+	bullets = append(bullets, bullet)
+}
+
+func (blaster *Blaster) Automatic() bool {
+	return true
+}
@@ -0,0 +1,119 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+* License, v. 2.0. If a copy of the MPL was not distributed with this
+* file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package main
+
+import (
+	"math"
+
+	"github.com/go-gl/gl/v2.1/gl"
+	glfw "github.com/go-gl/glfw3/v3.0/glfw"
+)
+
+const (
+	cameraFollowRate = 4    // How quickly the camera catches up with the ship, per second.
+	cullMargin       = 60   // Room for things sticking out of a copy of the field.
+	minZoom          = 0.25 // Furthest out the camera zooms.
+	maxZoom          = 4    // Furthest in the camera zooms.
+	zoomStep         = 1.25
+)
+
+var (
+	screenWidth float64 // Width of the screen in HUD units, fieldSize being its height.
+	zoom        float64 = 1
+	camera      Camera
+)
+
+// View of the local player into the world. If the world doesn't fit
+// the screen, it follows the local ship.
+type Camera struct {
+	X, Y            float64 // Center of the view.
+	lastUpdatedTime float64
+}
+
+// Size of the part of the world on screen.
+func viewSize() (float64, float64) {
+	return screenWidth / zoom, fieldSize / zoom
+}
+
+// Sets the size of the world from the game mode. Sizes the host left
+// at 0 fit the window.
+func resizeWorld() {
+	gameWidth, gameHeight = screenWidth, fieldSize
+	if mode.WorldWidth > 0 {
+		gameWidth = mode.WorldWidth
+	}
+	if mode.WorldHeight > 0 {
+		gameHeight = mode.WorldHeight
+	}
+}
+
+func setZoom(z float64) {
+	zoom = math.Max(minZoom, math.Min(maxZoom, z))
+}
+
+func zoomIn() {
+	setZoom(zoom * zoomStep)
+}
+
+func zoomOut() {
+	setZoom(zoom / zoomStep)
+}
+
+// Moves the camera smoothly after the local ship, or centers it on the
+// world if that fits the screen.
+func (camera *Camera) Update() {
+	timediff := glfw.GetTime() - camera.lastUpdatedTime
+	camera.lastUpdatedTime = glfw.GetTime()
+
+	viewWidth, viewHeight := viewSize()
+	dx, dy := wrappedOffset(camera.X, camera.Y, ship.PosX, ship.PosY)
+	catchUp := 1 - math.Exp(-cameraFollowRate*timediff)
+	camera.X = camera.follow(camera.X, dx*catchUp, viewWidth, gameWidth)
+	camera.Y = camera.follow(camera.Y, dy*catchUp, viewHeight, gameHeight)
+}
+
+// Moves the camera by d along one axis of the world, keeping it inside
+// the walls of an arena.
+func (camera *Camera) follow(at, d, view, world float64) float64 {
+	if view >= world {
+		return world / 2
+	}
+	at += d
+	if mode.Arena {
+		return math.Max(view/2, math.Min(world-view/2, at))
+	}
+	return math.Mod(at+world, world)
+}
+
+// Looks at the world through the camera.
+func (camera *Camera) Apply() {
+	viewWidth, viewHeight := viewSize()
+	gl.MatrixMode(gl.PROJECTION)
+	gl.LoadIdentity()
+	gl.Ortho(camera.X-viewWidth/2, camera.X+viewWidth/2, camera.Y-viewHeight/2, camera.Y+viewHeight/2, -1.0, 1.0)
+	gl.MatrixMode(gl.MODELVIEW)
+}
+
+// Copies of the field around the world the view overlaps. Only those
+// need to be drawn; an arena has no copies.
+func (camera *Camera) VisibleCopies() (minX, maxX, minY, maxY float64) {
+	if mode.Arena {
+		return 0, 0, 0, 0
+	}
+	viewWidth, viewHeight := viewSize()
+	minX = math.Floor((camera.X - viewWidth/2 - cullMargin) / gameWidth)
+	maxX = math.Floor((camera.X + viewWidth/2 + cullMargin) / gameWidth)
+	minY = math.Floor((camera.Y - viewHeight/2 - cullMargin) / gameHeight)
+	maxY = math.Floor((camera.Y + viewHeight/2 + cullMargin) / gameHeight)
+	return minX, maxX, minY, maxY
+}
+
+// Looks at the screen, for drawing the HUD.
+func hudProjection() {
+	gl.MatrixMode(gl.PROJECTION)
+	gl.LoadIdentity()
+	gl.Ortho(0, screenWidth, 0, fieldSize, -1.0, 1.0)
+	gl.MatrixMode(gl.MODELVIEW)
+}
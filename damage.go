@@ -0,0 +1,178 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+* License, v. 2.0. If a copy of the MPL was not distributed with this
+* file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package main
+
+import (
+	"fmt"
+	"math"
+
+	glfw "github.com/go-gl/glfw3/v3.0/glfw"
+)
+
+// Damage dealt by each kind of hit.
+const (
+	bulletDamage    = 1
+	laserDamage     = 1
+	torpedoDamage   = 2
+	collisionDamage = 2
+	explosionDamage = 4 // At the center of a big explosion, less further out.
+)
+
+// Hull points of a fresh ship.
+const maxHull = 3
+
+// Seconds a ship can't be hit again after taking damage.
+const hitInvulnerability = 0.5
+
+// Points scored for every hit point of damage done.
+const damagePoints = 1
+
+// Damage the local player has done this game.
+var damageDealt int
+
+// Hit points of an asteroid of the given size and material; big and
+// tough ones take a few hits before they split.
+func asteroidHealth(size float64, material Material) int {
+	return int(math.Ceil(size/3)) * materialTypes[material].Toughness
+}
+
+// Number floating up from where the local player did damage.
+type DamageNumber struct {
+	Entity
+	Amount      int
+	MaxLifetime float64
+}
+
+func NewDamageNumber(x, y float64, amount int) *DamageNumber {
+	return &DamageNumber{*NewEntity(Polygon{}, x, y, 0, 0, 0, 0.02, 0, 5), amount, 0.6}
+}
+
+func (number *DamageNumber) Update() {
+	if paused {
+		timediff := (glfw.GetTime() - number.Entity.lastUpdatedTime)
+		number.MaxLifetime = number.MaxLifetime + timediff
+	}
+	number.Entity.Update()
+}
+
+func (number *DamageNumber) Draw() {
+	if number.IsAlive() {
+		fade := 1 - (glfw.GetTime()-number.createdTime)/number.MaxLifetime
+		DrawString(number.PosX, number.PosY, 0.6, Color{fade, fade * 0.8, 0}, fmt.Sprintf("%d", number.Amount))
+	}
+}
+
+func (number *DamageNumber) IsAlive() bool {
+	if glfw.GetTime() > number.createdTime+number.MaxLifetime {
+		return false
+	}
+	return number.Entity.IsAlive()
+}
+
+// Keeps track of damage player owner did at x, y.
+func recordDamage(owner, amount int, x, y float64) {
+	if owner != PlayerId {
+		return
+	}
+	damageDealt += amount
+	addScore(amount * damagePoints)
+	damageNumbers = append(damageNumbers, NewDamageNumber(x+4, y+4, amount))
+}
+
+// Takes amount hit points off the asteroid, splitting it once none
+// are left.
+func (ast *Asteroid) Damage(amount, owner int, impact Impact) {
+	if !ast.IsAlive() {
+		return
+	}
+	recordDamage(owner, amount, ast.PosX, ast.PosY)
+	ast.impact = impact
+	ast.hitBy = owner
+	ast.Health -= amount
+	if ast.Health <= 0 {
+		ast.Destroy()
+	} else {
+		explosions = append(explosions, NewExplosion(ast.PosX, ast.PosY, ast.SizeRatio/4))
+	}
+}
+
+// Hits the ship for amount hull points, the hit coming from player
+// owner. Only the local ship takes the damage here; hits the local
+// player lands on other ships are reported to their players, who take
+// them off their hulls in updateHits unless their ship can't be hit.
+func (ship *Ship) Damage(amount, owner int) {
+	if !ship.CanBeHit() {
+		return
+	}
+	if owner != ship.PlayerId {
+		recordDamage(owner, amount, ship.PosX, ship.PosY)
+	}
+	if ship.PlayerId != PlayerId {
+		if owner == PlayerId {
+			reportHit(ship.PlayerId, amount)
+		}
+		// don't hit it again right away; its shield is copied
+		// from its player, so it can't hold this
+		ship.hitPause = glfw.GetTime() + hitInvulnerability
+		return
+	}
+	ship.TakeDamage(amount, owner)
+}
+
+// Takes amount hull points off the ship. The ship is destroyed once
+// its hull is gone, otherwise it gets a moment of shield to get away.
+func (ship *Ship) TakeDamage(amount, owner int) {
+	ship.Hull -= amount
+	if ship.Hull <= 0 {
+		destroyShip(ship.PlayerId, owner)
+	} else {
+		ship.Shield.RaiseFor(hitInvulnerability)
+	}
+}
+
+// Records a hit of amount hull points the local player landed on the
+// ship of player victim and lets the victim know through paxos.
+func reportHit(victim, amount int) {
+	hits[victim] += amount
+	gameNode.ShareHits(matchEpoch, hits)
+}
+
+// Takes the damage other players reported on the local ship off its
+// hull. Hits that land while the ship can't be hit, say with its
+// shield up, are ignored.
+func updateHits() {
+	for attacker, victims := range gameNode.GetHits(matchEpoch) {
+		if attacker == PlayerId {
+			continue
+		}
+		count := victims[PlayerId]
+		if count > hitsTaken[attacker] && ship.CanBeHit() {
+			ship.TakeDamage(count-hitsTaken[attacker], attacker)
+		}
+		hitsTaken[attacker] = count
+	}
+}
+
+// Damage the explosion does to ent, falling off from explosionDamage
+// at its center. Every explosion hurts an entity only once; returns 0
+// if it doesn't reach ent or has already hurt it.
+func (explosion *BigExplosion) DamageTo(ent *Entity) int {
+	if explosion.hit[ent] || !IsColliding(&explosion.Entity, ent) {
+		return 0
+	}
+	explosion.hit[ent] = true
+
+	reach := explosion.Radius() + ent.Radius()
+	d := wrappedDistance(explosion.PosX, explosion.PosY, ent.PosX, ent.PosY)
+	return int(math.Max(1, math.Ceil(explosionDamage*(1-d/reach))))
+}
+
+// Shows the local ship's hull next to the shield bar.
+func drawHullBar() {
+	if !ship.IsAlive() {
+		return
+	}
+	drawBar(75, fieldSize-60, 30, float64(ship.Hull)/maxHull, Color{1, 0.3, 0.3})
+}
@@ -28,6 +28,7 @@ type Entity struct {
 	isAlive          bool
 	createdTime      float64
 	lastUpdatedTime  float64
+	prevX, prevY     float64 // Position before the last move.
 }
 
 func NewEntity(shape Polygon, x, y, angle, turnrate, vX, vY, accel, maxvel float64) *Entity {
@@ -35,6 +36,8 @@ func NewEntity(shape Polygon, x, y, angle, turnrate, vX, vY, accel, maxvel float
 		Shape:            shape,
 		PosX:             x,
 		PosY:             y,
+		prevX:            x,
+		prevY:            y,
 		Angle:            angle,
 		TurnRate:         turnrate,
 		VelocityX:        vX,
@@ -139,9 +142,15 @@ func (ent *Entity) Update() {
 		}
 
 		// move
+		ent.prevX, ent.prevY = ent.PosX, ent.PosY
 		ent.PosX = ent.VelocityX*timediff + ent.PosX
 		ent.PosY = ent.VelocityY*timediff + ent.PosY
 
+		// a bounded arena has walls instead, see arenaHitDetection
+		if mode.Arena {
+			return
+		}
+
 		// crude zone clipping
 		// TODO: for now it works, but needs to be updated for seamless clipping..
 		if ent.PosX > gameWidth {
@@ -157,6 +166,19 @@ func (ent *Entity) Update() {
 	}
 }
 
+func (ent *Entity) Position() (float64, float64) {
+	return ent.PosX, ent.PosY
+}
+
+// Distance from the entity's center to its farthest vertex.
+func (ent *Entity) Radius() float64 {
+	radius := 0.0
+	for _, v := range ent.Shape.Vectors {
+		radius = math.Max(radius, math.Hypot(v.X, v.Y))
+	}
+	return radius
+}
+
 func (ent *Entity) AddFrictionToVelocity(friction float64) {
 	frict := (friction / 100)
 
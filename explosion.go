@@ -33,6 +33,41 @@ func NewExplosion(x, y, size float64) *Explosion {
 	return explosion
 }
 
+// Burst of blue lines for a ship leaving or, inward, entering hyperspace.
+func NewTeleportEffect(x, y float64, inward bool) *Explosion {
+	explosion := NewExplosion(x, y, 3)
+	explosion.MaxLifetime = 0.5
+	for _, line := range explosion.Lines {
+		line.Shape.Colors = []Color{
+			Color{0.3, 0.8, 1},
+			Color{0.6, 0.9, 1},
+		}
+		if inward {
+			// start out on a ring and collapse into the center
+			line.PosX += line.VelocityX * 250 * explosion.MaxLifetime
+			line.PosY += line.VelocityY * 250 * explosion.MaxLifetime
+			line.VelocityX = -line.VelocityX
+			line.VelocityY = -line.VelocityY
+		}
+	}
+	return explosion
+}
+
+// Grey bits left of a shard too small to keep.
+func NewDebris(x, y, size float64) *Explosion {
+	explosion := NewExplosion(x, y, size)
+	explosion.MaxLifetime = 0.6
+	for _, line := range explosion.Lines {
+		line.Shape.Colors = []Color{
+			Color{0.7, 0.7, 0.7},
+			Color{0.5, 0.5, 0.5},
+		}
+		line.VelocityX /= 2
+		line.VelocityY /= 2
+	}
+	return explosion
+}
+
 func NewExplosionLine(x, y, velocity, size float64) *ExplosionLine {
 	shape := Polygon{
 		[]Vector{
@@ -0,0 +1,291 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+* License, v. 2.0. If a copy of the MPL was not distributed with this
+* file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package main
+
+import (
+	"math"
+
+	"github.com/paulsmith/gogeos/geos"
+)
+
+// Shards smaller than this turn into debris.
+const debrisArea = 15.0
+
+// Speed at which shards fly away from where the rock was.
+const fractureSpeed = 0.05
+
+// Rocks at least this big get cut twice, across the shot as well.
+const crossCutSize = 6.0
+
+// One cut through a rock, in the rock's own frame, and which piece of
+// it a shard is.
+type Cut struct {
+	Angle  float64 // Heading of the cut line.
+	Offset float64 // Signed distance of the line from the rock's center.
+	Side   int     // Side of the line the shard is on, 1 or -1.
+	Part   int     // Which shard on that side, if the cut made several.
+}
+
+// How a shard came out of a whole rock: the size of the rock and the
+// cuts that made it. Together with the seed it is all peers need to
+// rebuild the shard's outline.
+type Fracture struct {
+	Size float64
+	Cuts []Cut
+}
+
+// Area of a polygon, however its vertices wind.
+func polygonArea(vectors []Vector) float64 {
+	area := 0.0
+	n := len(vectors)
+	for v := 0; v < n; v++ {
+		a, b := vectors[v], vectors[(v+1)%n]
+		area += a.X*b.Y - b.X*a.Y
+	}
+	return math.Abs(area) / 2
+}
+
+func polygonCentroid(vectors []Vector) Vector {
+	var cx, cy, area float64
+	n := len(vectors)
+	for v := 0; v < n; v++ {
+		a, b := vectors[v], vectors[(v+1)%n]
+		cross := a.X*b.Y - b.X*a.Y
+		area += cross
+		cx += (a.X + b.X) * cross
+		cy += (a.Y + b.Y) * cross
+	}
+	if area == 0 {
+		return Vector{0, 0}
+	}
+	return Vector{cx / (3 * area), cy / (3 * area)}
+}
+
+// Pieces of the polygon on one side of the line with heading angle at
+// offset from the origin. A concave polygon can fall apart into
+// several pieces on the same side. Fails if GEOS can't make sense of
+// the polygon, say because it is degenerate.
+func cutPolygon(vectors []Vector, angle, offset float64, side int) ([][]Vector, error) {
+	var shell []geos.Coord
+	for _, v := range vectors {
+		shell = append(shell, geos.Coord{v.X, v.Y, 1})
+	}
+	shell = append(shell, geos.Coord{vectors[0].X, vectors[0].Y, 1})
+	polygon, err := geos.NewPolygon(shell)
+	if err != nil {
+		return nil, err
+	}
+
+	// a big box covering everything on that side of the line
+	var rad float64 = ((angle) * math.Pi) / 180
+	dx, dy := math.Sin(rad), math.Cos(rad)
+	nx, ny := dy*float64(side), -dx*float64(side)
+	far := 1000.0
+	baseX, baseY := dy*offset, -dx*offset
+	halfPlane, err := geos.NewPolygon([]geos.Coord{
+		geos.Coord{baseX + dx*far, baseY + dy*far, 1},
+		geos.Coord{baseX - dx*far, baseY - dy*far, 1},
+		geos.Coord{baseX - dx*far + nx*far, baseY - dy*far + ny*far, 1},
+		geos.Coord{baseX + dx*far + nx*far, baseY + dy*far + ny*far, 1},
+		geos.Coord{baseX + dx*far, baseY + dy*far, 1},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	intersection, err := polygon.Intersection(halfPlane)
+	if err != nil {
+		return nil, err
+	}
+	var pieces [][]Vector
+	var collect func(g *geos.Geometry) error
+	collect = func(g *geos.Geometry) error {
+		kind, err := g.Type()
+		if err != nil {
+			return err
+		}
+		switch kind {
+		case geos.POLYGON:
+			ring, err := g.Shell()
+			if err != nil {
+				return err
+			}
+			coords, err := ring.Coords()
+			if err != nil {
+				return err
+			}
+			var piece []Vector
+			for i := 0; i < len(coords)-1; i++ {
+				piece = append(piece, Vector{coords[i].X, coords[i].Y})
+			}
+			if len(piece) >= 3 {
+				pieces = append(pieces, piece)
+			}
+		case geos.MULTIPOLYGON, geos.GEOMETRYCOLLECTION:
+			n, err := g.NGeometry()
+			if err != nil {
+				return err
+			}
+			for i := 0; i < n; i++ {
+				part, err := g.Geometry(i)
+				if err != nil {
+					return err
+				}
+				if err := collect(part); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	if err := collect(intersection); err != nil {
+		return nil, err
+	}
+	return pieces, nil
+}
+
+// Moves the polygon so its centroid is at the origin. Returns where
+// the centroid was.
+func recenter(vectors []Vector) Vector {
+	c := polygonCentroid(vectors)
+	for v := range vectors {
+		vectors[v].X -= c.X
+		vectors[v].Y -= c.Y
+	}
+	return c
+}
+
+// Applies one cut to an outline, returning the shard's outline
+// around its own centroid, or nil if the cut has no such shard.
+func (cut Cut) Apply(vectors []Vector) []Vector {
+	pieces, err := cutPolygon(vectors, cut.Angle, cut.Offset, cut.Side)
+	if err != nil || cut.Part >= len(pieces) {
+		return nil
+	}
+	piece := pieces[cut.Part]
+	recenter(piece)
+	return piece
+}
+
+// Outline of the shard cut out of the rock grown from seed.
+func (fracture *Fracture) Shape(seed int64) Polygon {
+	vectors := asteroidShape(seed, fracture.Size).Vectors
+	for _, cut := range fracture.Cuts {
+		if next := cut.Apply(vectors); next != nil {
+			vectors = next
+		}
+	}
+	return Polygon{vectors, nil}
+}
+
+// Turns the asteroid into a shard described by fracture.
+func (ast *Asteroid) SetFracture(fracture *Fracture) {
+	ast.Fracture = fracture
+	ast.Shape = fracture.Shape(ast.Seed)
+	ast.paint()
+}
+
+type shard struct {
+	vectors []Vector
+	center  Vector // Centroid in the frame of the rock it was cut from.
+	cuts    []Cut
+}
+
+// Cuts the outline along each line in turn, line i given by angles[i]
+// and offsets[i] in the outline's frame.
+func shatter(vectors []Vector, angles, offsets []float64) ([]shard, error) {
+	shards := []shard{shard{vectors, Vector{0, 0}, nil}}
+	for i := range angles {
+		var next []shard
+		for _, s := range shards {
+			// the line, moved into the frame of the shard
+			var rad float64 = ((angles[i]) * math.Pi) / 180
+			offset := offsets[i] - (s.center.X*math.Cos(rad) - s.center.Y*math.Sin(rad))
+
+			for _, side := range []int{1, -1} {
+				pieces, err := cutPolygon(s.vectors, angles[i], offset, side)
+				if err != nil {
+					return nil, err
+				}
+				for part, piece := range pieces {
+					c := recenter(piece)
+					cuts := append(append([]Cut(nil), s.cuts...), Cut{angles[i], offset, side, part})
+					next = append(next, shard{piece, Vector{s.center.X + c.X, s.center.Y + c.Y}, cuts})
+				}
+			}
+		}
+		shards = next
+	}
+	return shards, nil
+}
+
+// Breaks the asteroid into shards along the line of its last hit.
+// Shards too small to matter become debris. Reports false, leaving
+// the asteroid be, if it can't be cut.
+func (ast *Asteroid) Shatter() bool {
+	impact := ast.impact
+
+	// the shot's path in the asteroid's own frame
+	dx, dy := impact.ImpulseX, impact.ImpulseY
+	if dx == 0 && dy == 0 {
+		dx, dy = wrappedOffset(impact.X, impact.Y, ast.PosX, ast.PosY)
+	}
+	heading := headingTo(0, 0, dx, dy) - ast.Angle
+	if dx == 0 && dy == 0 {
+		heading = rng.Float64() * 360
+	}
+	ix, iy := wrappedOffset(ast.PosX, ast.PosY, impact.X, impact.Y)
+	px, py := (&Vector{ix, iy}).Rotate(-ast.Angle)
+	var rad float64 = ((heading) * math.Pi) / 180
+	offset := px*math.Cos(rad) - py*math.Sin(rad)
+
+	angles, offsets := []float64{heading}, []float64{offset}
+	if ast.SizeRatio >= crossCutSize {
+		cross := heading + 90
+		var crossRad float64 = ((cross) * math.Pi) / 180
+		angles = append(angles, cross)
+		offsets = append(offsets, px*math.Cos(crossRad)-py*math.Sin(crossRad))
+	}
+
+	parent := Fracture{ast.SizeRatio, nil}
+	if ast.Fracture != nil {
+		parent = *ast.Fracture
+	}
+	shards, err := shatter(ast.Shape.Vectors, angles, offsets)
+	if err != nil {
+		return false
+	}
+	area := polygonArea(ast.Shape.Vectors)
+	for _, s := range shards {
+		cx, cy := s.center.Rotate(ast.Angle)
+		x, y := ast.PosX+cx, ast.PosY+cy
+		shardArea := polygonArea(s.vectors)
+		if shardArea < debrisArea {
+			explosions = append(explosions, NewDebris(x, y, math.Sqrt(shardArea)/2))
+			continue
+		}
+
+		fracture := &Fracture{parent.Size, append(append([]Cut(nil), parent.Cuts...), s.cuts...)}
+		d := math.Hypot(cx, cy)
+		vX, vY := ast.VelocityX, ast.VelocityY
+		if d > 0 {
+			speed := fractureSpeed * materialTypes[ast.Material].SplitSpeed
+			vX += cx / d * speed
+			vY += cy / d * speed
+		}
+
+		size := ast.SizeRatio * math.Sqrt(shardArea/area)
+		piece := NewAsteroid(x, y, ast.Angle, rng.Float64()/10, vX, vY, size, ast.Lives-1, ast.Seed, ast.Material)
+		piece.SetFracture(fracture)
+		if rng.Float64() > 0.5 {
+			piece.RotateRight(true)
+		} else {
+			piece.RotateLeft(true)
+		}
+		asteroids[piece.Id] = piece
+		gameNode.ShareAsteroidFracture(piece.Id, fracture)
+	}
+	return true
+}
@@ -7,30 +7,47 @@ import (
 	"fmt"
 	"net/rpc"
 	"encoding/json"
+	"sort"
 	"strconv"
 
 	"github.com/cmu440-F15/paxosapp/paxos"
 	"github.com/cmu440-F15/paxosapp/rpc/paxosrpc"
 )
 
+// What a player tells the others about itself when joining.
+type PlayerInfo struct {
+	Team int
+}
+
 // Wrapper around Paxos to store and get commonly used values for
 // the game.
 type GameNode struct {
 	node paxos.PaxosNode
 	address string
 	playerAddresses map[string]string
+	playerNames map[string]string
 	PlayerId int
+	Name string
+	Mode GameMode
+	Level *Level // Obstacles and wells the host loaded, nil for none.
 }
 
 // Start a GameNode as a server, ie., host a game.
-// hostAddress is the port to host the game one.
-func NewGameServer(hostAddress string) (*GameNode, error) {
+// hostAddress is the port to host the game one, mode the rules
+// every player of the game will play by, level the layout to play
+// on (or nil) and name the name of the hosting player.
+func NewGameServer(hostAddress string, mode GameMode, level *Level, name string) (*GameNode, error) {
 	gs := new(GameNode)
 	gs.address = hostAddress
 	gs.playerAddresses = make(map[string]string)
+	gs.playerNames = make(map[string]string)
 	gs.PlayerId = 0
+	gs.Name = name
+	gs.Mode = mode
+	gs.Level = level
 
 	gs.playerAddresses["0"] = hostAddress
+	gs.playerNames["0"] = name
 
 	hostMap := make(map[int]string)
 	hostMap[0] = hostAddress
@@ -46,10 +63,12 @@ func NewGameServer(hostAddress string) (*GameNode, error) {
 }
 
 // Start a GameNode as a client, ie., connect to a hosted game.
-// serverHostAddress is the address of the server to connect to.
-func NewGameClient(myHostAddress, serverHostAddress string) (*GameNode, error) {
+// serverHostAddress is the address of the server to connect to,
+// name the name the other players will see.
+func NewGameClient(myHostAddress, serverHostAddress, name string) (*GameNode, error) {
 	gs := new(GameNode)
 	gs.address = myHostAddress
+	gs.Name = name
 
 	// Contact server for player info.
 	playerAddresses, err := gs.GetPlayerAddresses(serverHostAddress)
@@ -57,9 +76,34 @@ func NewGameClient(myHostAddress, serverHostAddress string) (*GameNode, error) {
 		return nil, err
 	} 
 
+	// Play by the rules the server picked.
+	modeEncoded, err := gs.getServerValue(serverHostAddress, "game_mode")
+	if err != nil {
+		return nil, err
+	}
+	json.Unmarshal([]byte(modeEncoded), &gs.Mode)
+
+	// And on the level the server loaded, if any.
+	levelEncoded, err := gs.getServerValue(serverHostAddress, "level")
+	if err != nil {
+		return nil, err
+	}
+	json.Unmarshal([]byte(levelEncoded), &gs.Level)
+
+	// Contact server for the names of the other players.
+	namesEncoded, err := gs.getServerValue(serverHostAddress, "player_names")
+	if err != nil {
+		return nil, err
+	}
+	json.Unmarshal([]byte(namesEncoded), &gs.playerNames)
+	if gs.playerNames == nil {
+		gs.playerNames = make(map[string]string)
+	}
+
 	gs.playerAddresses = playerAddresses
 	gs.PlayerId = len(gs.playerAddresses)
 	gs.playerAddresses[strconv.Itoa(gs.PlayerId)] = myHostAddress
+	gs.playerNames[strconv.Itoa(gs.PlayerId)] = name
 
 	// Convert string->string map into int->string map.
 	// Note that JSON won't let us encode int->string maps for
@@ -86,6 +130,10 @@ func NewGameClient(myHostAddress, serverHostAddress string) (*GameNode, error) {
 	playerAddressesEncoded := string(v)
 	gs.MakeProposal("player_addresses", playerAddressesEncoded)
 
+	// Update the player names map.
+	v, _ = json.Marshal(gs.playerNames)
+	gs.MakeProposal("player_names", string(v))
+
 	return gs, nil
 }
 
@@ -150,17 +198,57 @@ func (gs *GameNode) InitializeGame() {
 	if err != nil {
 		panic("Could not initialize game server")
 	}
+
+	v, _ = json.Marshal(gs.playerNames)
+	_, err = gs.MakeProposal("player_names", string(v))
+	if err != nil {
+		panic("Could not initialize game server")
+	}
+
+	v, _ = json.Marshal(gs.Mode)
+	_, err = gs.MakeProposal("game_mode", string(v))
+	if err != nil {
+		panic("Could not initialize game server")
+	}
+
+	v, _ = json.Marshal(gs.Level)
+	_, err = gs.MakeProposal("level", string(v))
+	if err != nil {
+		panic("Could not initialize game server")
+	}
+}
+
+// Ids of all players registered with the game, in ascending order.
+func (gs *GameNode) PlayerIds() []int {
+	var ids []int
+	for k := range(gs.playerAddresses) {
+		i, _ := strconv.Atoi(k)
+		ids = append(ids, i)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// Name player id joined the game with.
+func (gs *GameNode) PlayerName(id int) string {
+	name := gs.playerNames[strconv.Itoa(id)]
+	if name == "" {
+		name = fmt.Sprintf("player %d", id)
+	}
+	return name
 }
 
 // Send information for player ship to the rest of the
 // game nodes.
 func (gs *GameNode) SharePlayer(ship *Ship) {
 	playerKey := fmt.Sprintf("player_%v", PlayerId)
-	playerPos := fmt.Sprintf("(%v,%v,%v,%v,%v,%v,%v,%v)", 
+	playerPos := fmt.Sprintf("(%v,%v,%v,%v,%v,%v,%v,%v,%v,%v,%v,%v,%v,%v)", 
 		ship.PosX, ship.PosY, ship.Angle,
 		ship.VelocityX, ship.VelocityY,
 		ship.TurnRate, ship.AccelerationRate,
-		ship.IsAlive())
+		ship.IsAlive(), ship.Shield.raised,
+		ship.Shield.Energy, ship.Shield.FreeTime(),
+		ship.Hyperspace.Jumps, ship.Hull, ship.LastHitBy)
 
 	_, err := gs.MakeProposal(playerKey, playerPos)	
 	if err != nil {
@@ -169,10 +257,41 @@ func (gs *GameNode) SharePlayer(ship *Ship) {
 }
 
 
+// Send the local player's PlayerInfo to the rest of the game nodes.
+func (gs *GameNode) SharePlayerInfo(info PlayerInfo) {
+	v, _ := json.Marshal(info)
+	_, err := gs.MakeProposal(fmt.Sprintf("player_info_%v", PlayerId), string(v))
+	if err != nil {
+		println("Was not able to share the info for player", PlayerId)
+	}
+}
+
+// Get the PlayerInfo of every player that has shared one.
+func (gs *GameNode) GetPlayerInfos() map[int]PlayerInfo {
+	m := make(map[int]PlayerInfo)
+
+	for id := range(gs.playerAddresses) {
+		infoEncoded, err := gs.GetValue(fmt.Sprintf("player_info_%v", id))
+		if err != nil {
+			continue
+		}
+
+		var info PlayerInfo
+		json.Unmarshal([]byte(infoEncoded), &info)
+
+		i, _ := strconv.Atoi(id)
+		m[i] = info
+	}
+
+	return m
+}
+
 // Get player information (ship positions) from paxos nodes.
 func (gs *GameNode) GetPlayers() map[int]*Ship{
 	encodedPlayerAddresses, _ := gs.GetValue("player_addresses")
 	json.Unmarshal([]byte(encodedPlayerAddresses), &gs.playerAddresses)
+	encodedPlayerNames, _ := gs.GetValue("player_names")
+	json.Unmarshal([]byte(encodedPlayerNames), &gs.playerNames)
 
 	m := make(map[int]*Ship)
 
@@ -182,10 +301,11 @@ func (gs *GameNode) GetPlayers() map[int]*Ship{
 
 		// Only worry about players who we have positions for.
 		if err == nil {
-			var x,y,angle,vX,vY,turnRate,accelerationRate float64
-			var isAlive bool
+			var x,y,angle,vX,vY,turnRate,accelerationRate,shieldEnergy,shieldFree float64
+			var isAlive,shieldRaised bool
+			var jumps,hull,lastHitBy int
 
-			fmt.Sscanf(posString, "(%v,%v,%v,%v,%v,%v,%v,%v)", &x,&y,&angle,&vX,&vY,&turnRate,&accelerationRate,&isAlive)
+			fmt.Sscanf(posString, "(%v,%v,%v,%v,%v,%v,%v,%v,%v,%v,%v,%v,%v,%v)", &x,&y,&angle,&vX,&vY,&turnRate,&accelerationRate,&isAlive,&shieldRaised,&shieldEnergy,&shieldFree,&jumps,&hull,&lastHitBy)
 			
 			newShip:=new(Ship)
 
@@ -197,6 +317,14 @@ func (gs *GameNode) GetPlayers() map[int]*Ship{
 			newShip.TurnRate=turnRate
 			newShip.AccelerationRate=accelerationRate
 			newShip.isAlive=isAlive
+			newShip.Shield=NewShield()
+			newShip.Shield.Raise(shieldRaised)
+			newShip.Shield.Energy=shieldEnergy
+			newShip.Shield.RaiseFor(shieldFree)
+			newShip.Hyperspace=NewHyperspace()
+			newShip.Hyperspace.Jumps=jumps
+			newShip.Hull=hull
+			newShip.LastHitBy=lastHitBy
 
 			i, _ := strconv.Atoi(id)
 			m[i] = newShip
@@ -217,11 +345,12 @@ func (gs *GameNode) ShareAsteroids(asteroids map[int]*Asteroid) {
 
 		// Share asteroid data.
 		asteroidKey := fmt.Sprintf("asteroid_%v", i)
-		asteroidPos := fmt.Sprintf("(%v,%v,%v,%v,%v,%v,%v,%v,%v)", 
+		asteroidPos := fmt.Sprintf("(%v,%v,%v,%v,%v,%v,%v,%v,%v,%v,%v,%v,%v)", 
 			asteroid.PosX, asteroid.PosY, asteroid.Angle,
 			asteroid.VelocityX, asteroid.VelocityY,
 			asteroid.TurnRate, asteroid.AccelerationRate,
-			asteroid.SizeRatio, asteroid.Lives)
+			asteroid.SizeRatio, asteroid.Lives, asteroid.Health,
+			asteroid.Seed, int(asteroid.Material), asteroid.Fracture != nil)
 		gs.MakeProposal(asteroidKey, asteroidPos)
 	}
 
@@ -244,10 +373,12 @@ func (gs *GameNode) GetAsteroids() map[int]*Asteroid {
 		asteroidEncoded, _ := gs.GetValue(asteroidKey)
 
 		var posX, posY, angle, turnRate, vX, vY, acceleration, size float64
-		var lives int
+		var lives, health, material int
+		var seed int64
+		var shard bool
 
-		fmt.Sscanf(asteroidEncoded, "(%v,%v,%v,%v,%v,%v,%v,%v,%v)", &posX, &posY,
-			&angle, &vX, &vY, &turnRate, &acceleration, &size, &lives)
+		fmt.Sscanf(asteroidEncoded, "(%v,%v,%v,%v,%v,%v,%v,%v,%v,%v,%v,%v,%v)", &posX, &posY,
+			&angle, &vX, &vY, &turnRate, &acceleration, &size, &lives, &health, &seed, &material, &shard)
 
 		asteroid := new(Asteroid)
 		asteroid.PosX = posX
@@ -259,6 +390,10 @@ func (gs *GameNode) GetAsteroids() map[int]*Asteroid {
 		asteroid.SizeRatio = size
 		asteroid.Id = id
 		asteroid.Lives = lives
+		asteroid.Health = health
+		asteroid.Seed = seed
+		asteroid.Material = Material(material)
+		asteroid.shard = shard
 
 		asteroids[id] = asteroid
 	}
@@ -266,28 +401,473 @@ func (gs *GameNode) GetAsteroids() map[int]*Asteroid {
 	return asteroids
 }
 
+// Share how a shard was cut out of its rock. Written once when the
+// shard is made; its outline never changes after that.
+func (gs *GameNode) ShareAsteroidFracture(id int, fracture *Fracture) {
+	v, _ := json.Marshal(fracture)
+	_, err := gs.MakeProposal(fmt.Sprintf("asteroid_fracture_%v", id), string(v))
+	if err != nil {
+		println("Was not able to share the fracture of asteroid", id)
+	}
+}
+
+// Get how asteroid id was cut out of its rock, nil for a whole rock.
+func (gs *GameNode) GetAsteroidFracture(id int) *Fracture {
+	encoded, err := gs.GetValue(fmt.Sprintf("asteroid_fracture_%v", id))
+	if err != nil {
+		return nil
+	}
+	fracture := new(Fracture)
+	if err := json.Unmarshal([]byte(encoded), fracture); err != nil {
+		return nil
+	}
+	return fracture
+}
+
+// Share how many times the local player destroyed each other
+// player's ship. Only the attacker ever writes its own key, so
+// victims can poll it without conflicting proposals.
+func (gs *GameNode) ShareFrags(epoch int, kills map[int]int) {
+	gs.shareTally("frags", epoch, kills)
+}
+
+// Get frags of all players in match epoch, indexed by attacker and
+// then by victim.
+func (gs *GameNode) GetFrags(epoch int) map[int]map[int]int {
+	return gs.getTally("frags", epoch)
+}
+
+// Share the damage the local player did to each other player's ship in
+// match epoch, indexed by victim.
+func (gs *GameNode) ShareHits(epoch int, hits map[int]int) {
+	gs.shareTally("hits", epoch, hits)
+}
+
+// Get the damage all players did to each other in match epoch, indexed
+// by attacker and then by victim.
+func (gs *GameNode) GetHits(epoch int) map[int]map[int]int {
+	return gs.getTally("hits", epoch)
+}
+
+// Share counts the local player keeps of something it did to other
+// players, under name for match epoch.
+func (gs *GameNode) shareTally(name string, epoch int, counts map[int]int) {
+	// JSON won't encode int->int maps, use string keys.
+	encoded := make(map[string]int)
+	for victim, count := range(counts) {
+		encoded[strconv.Itoa(victim)] = count
+	}
+	v, _ := json.Marshal(encoded)
+
+	key := fmt.Sprintf("%v_%v_%v", name, epoch, PlayerId)
+	_, err := gs.MakeProposal(key, string(v))
+	if err != nil {
+		println("Was not able to share the", name, "for player", PlayerId)
+	}
+}
+
+// Get the counts all players shared under name for match epoch,
+// indexed by attacker and then by victim.
+func (gs *GameNode) getTally(name string, epoch int) map[int]map[int]int {
+	m := make(map[int]map[int]int)
+
+	for id := range(gs.playerAddresses) {
+		encodedTally, err := gs.GetValue(fmt.Sprintf("%v_%v_%v", name, epoch, id))
+		if err != nil {
+			continue
+		}
+
+		var encoded map[string]int
+		json.Unmarshal([]byte(encodedTally), &encoded)
+
+		attacker, _ := strconv.Atoi(id)
+		m[attacker] = make(map[int]int)
+		for k, count := range(encoded) {
+			victim, _ := strconv.Atoi(k)
+			m[attacker][victim] = count
+		}
+	}
+
+	return m
+}
+
+// Share the match being played: its epoch, counting up with every
+// new match, and when it started in seconds since the Unix epoch.
+// Only the host starts matches.
+func (gs *GameNode) ShareMatch(epoch int, start float64) {
+	_, err := gs.MakeProposal("match", fmt.Sprintf("(%v,%v)", epoch, start))
+	if err != nil {
+		println("Was not able to share the match")
+	}
+}
+
+// Share the level the host is playing.
+func (gs *GameNode) ShareGameLevel(level int) {
+	_, err := gs.MakeProposal("game_level", strconv.Itoa(level))
+	if err != nil {
+		println("Was not able to share the level")
+	}
+}
+
+// Get the level the host is playing.
+func (gs *GameNode) GetGameLevel() (int, error) {
+	levelEncoded, err := gs.GetValue("game_level")
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(levelEncoded)
+}
+
+// Get the epoch and start time of the match the host started.
+func (gs *GameNode) GetMatch() (int, float64, error) {
+	matchEncoded, err := gs.GetValue("match")
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var epoch int
+	var start float64
+	fmt.Sscanf(matchEncoded, "(%v,%v)", &epoch, &start)
+	return epoch, start, nil
+}
+
+// Ask the host to follow match epoch up with a new one.
+func (gs *GameNode) ShareMatchRequest(epoch int) {
+	_, err := gs.MakeProposal(fmt.Sprintf("match_request_%v", PlayerId), strconv.Itoa(epoch))
+	if err != nil {
+		println("Was not able to share the match request for player", PlayerId)
+	}
+}
+
+// Get the epochs players asked to end.
+func (gs *GameNode) GetMatchRequests() []int {
+	var epochs []int
+	for id := range(gs.playerAddresses) {
+		v, err := gs.GetValue(fmt.Sprintf("match_request_%v", id))
+		if err != nil {
+			continue
+		}
+
+		epoch, _ := strconv.Atoi(v)
+		epochs = append(epochs, epoch)
+	}
+
+	return epochs
+}
+
+// Share saucer information. Only the host runs saucers.
+func (gs *GameNode) ShareSaucers(saucers map[int]*Saucer) {
+	saucerIds := make([]int, 0, len(saucers))
+	for id, saucer := range(saucers) {
+		saucerIds = append(saucerIds, id)
+
+		saucerKey := fmt.Sprintf("saucer_%v", id)
+		saucerPos := fmt.Sprintf("(%v,%v,%v,%v,%v,%v,%v)",
+			saucer.PosX, saucer.PosY,
+			saucer.VelocityX, saucer.VelocityY,
+			saucer.Small, saucer.Shots, saucer.AimAngle)
+		gs.MakeProposal(saucerKey, saucerPos)
+	}
+
+	saucerIdsEncoded, _ := json.Marshal(saucerIds)
+	gs.MakeProposal("saucer_ids", string(saucerIdsEncoded))
+}
+
+// Get saucer information.
+func (gs *GameNode) GetSaucers() map[int]*Saucer {
+	var saucerIds []int
+	saucerIdsEncoded, _ := gs.GetValue("saucer_ids")
+	json.Unmarshal([]byte(saucerIdsEncoded), &saucerIds)
+
+	saucers := make(map[int]*Saucer)
+	for _, id := range(saucerIds) {
+		saucerEncoded, err := gs.GetValue(fmt.Sprintf("saucer_%v", id))
+		if err != nil {
+			continue
+		}
+
+		saucer := new(Saucer)
+		fmt.Sscanf(saucerEncoded, "(%v,%v,%v,%v,%v,%v,%v)",
+			&saucer.PosX, &saucer.PosY,
+			&saucer.VelocityX, &saucer.VelocityY,
+			&saucer.Small, &saucer.Shots, &saucer.AimAngle)
+		saucer.Id = id
+
+		saucers[id] = saucer
+	}
+
+	return saucers
+}
+
+// Share the boss, or that there is none. Only the host runs it.
+func (gs *GameNode) ShareBoss(boss *Boss) {
+	if boss == nil {
+		gs.MakeProposal("boss", "none")
+		return
+	}
+	bossState := fmt.Sprintf("(%v,%v,%v,%v,%v,%v,%v,%v,%v,%v,%v)",
+		boss.Id, boss.Level,
+		boss.PosX, boss.PosY, boss.Angle,
+		boss.VelocityX, boss.VelocityY,
+		boss.Phase, boss.Shots, boss.AimAngle,
+		boss.IsAlive())
+	gs.MakeProposal("boss", bossState)
+
+	health := make([]int, len(boss.WeakPoints))
+	for i, point := range(boss.WeakPoints) {
+		health[i] = point.Health
+	}
+	v, _ := json.Marshal(health)
+	gs.MakeProposal("boss_health", string(v))
+}
+
+// Get the boss the host runs, nil if there is none.
+func (gs *GameNode) GetBoss() *Boss {
+	bossEncoded, err := gs.GetValue("boss")
+	if err != nil || bossEncoded == "none" {
+		return nil
+	}
+
+	var id, level int
+	var alive bool
+	boss := new(Boss)
+	fmt.Sscanf(bossEncoded, "(%v,%v,%v,%v,%v,%v,%v,%v,%v,%v,%v)",
+		&id, &level,
+		&boss.PosX, &boss.PosY, &boss.Angle,
+		&boss.VelocityX, &boss.VelocityY,
+		&boss.Phase, &boss.Shots, &boss.AimAngle,
+		&alive)
+	boss.Id = id
+	boss.Level = level
+	boss.isAlive = alive
+
+	var health []int
+	healthEncoded, _ := gs.GetValue("boss_health")
+	json.Unmarshal([]byte(healthEncoded), &health)
+	for _, h := range(health) {
+		boss.WeakPoints = append(boss.WeakPoints, &WeakPoint{Health: h})
+	}
+
+	return boss
+}
+
+// Tell the host how much damage the local player did to each weak
+// point of boss id.
+func (gs *GameNode) ShareBossDamage(id int, damage []int) {
+	v, _ := json.Marshal(damage)
+	_, err := gs.MakeProposal(fmt.Sprintf("boss_damage_%v_%v", id, PlayerId), string(v))
+	if err != nil {
+		println("Was not able to share the boss damage for player", PlayerId)
+	}
+}
+
+// Get the damage all other players did to each weak point of boss id.
+func (gs *GameNode) GetBossDamage(id int) []int {
+	var total []int
+	for playerId := range(gs.playerAddresses) {
+		if playerId == strconv.Itoa(PlayerId) {
+			continue
+		}
+		damageEncoded, err := gs.GetValue(fmt.Sprintf("boss_damage_%v_%v", id, playerId))
+		if err != nil {
+			continue
+		}
+
+		var damage []int
+		json.Unmarshal([]byte(damageEncoded), &damage)
+		for i, d := range(damage) {
+			if i >= len(total) {
+				total = append(total, 0)
+			}
+			total[i] += d
+		}
+	}
+
+	return total
+}
+
+// Share things the host placed for the level, like gravity wells or
+// portals, under key. Only the host places them.
+func (gs *GameNode) ShareLevelObjects(key string, objects interface{}) {
+	v, _ := json.Marshal(objects)
+	gs.MakeProposal(key, string(v))
+}
+
+// Get the things the host placed under key into objects, a pointer
+// to a slice of them.
+func (gs *GameNode) GetLevelObjects(key string, objects interface{}) error {
+	objectsEncoded, err := gs.GetValue(key)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal([]byte(objectsEncoded), objects)
+}
+
+// Tell the host which of its saucers the local player shot down.
+func (gs *GameNode) ShareSaucerKills(kills map[int]bool) {
+	ids := make([]int, 0, len(kills))
+	for id := range(kills) {
+		ids = append(ids, id)
+	}
+	v, _ := json.Marshal(ids)
+
+	_, err := gs.MakeProposal(fmt.Sprintf("saucer_kills_%v", PlayerId), string(v))
+	if err != nil {
+		println("Was not able to share the saucer kills for player", PlayerId)
+	}
+}
+
+// Get the ids of all saucers shot down by any player.
+func (gs *GameNode) GetSaucerKills() []int {
+	var kills []int
+	for id := range(gs.playerAddresses) {
+		killsEncoded, err := gs.GetValue(fmt.Sprintf("saucer_kills_%v", id))
+		if err != nil {
+			continue
+		}
+
+		var ids []int
+		json.Unmarshal([]byte(killsEncoded), &ids)
+		kills = append(kills, ids...)
+	}
+
+	return kills
+}
+
+// Share a power-up the local player dropped, along with the ids of
+// all power-ups it has dropped so far.
+func (gs *GameNode) SharePowerUp(powerUp *PowerUp, spawned []int) {
+	powerUpKey := fmt.Sprintf("powerup_%v", powerUp.Id)
+	powerUpPos := fmt.Sprintf("(%v,%v,%v)", powerUp.PosX, powerUp.PosY, int(powerUp.Kind))
+	gs.MakeProposal(powerUpKey, powerUpPos)
+	gs.SharePowerUpIds(spawned)
+}
+
+// Share the ids of the power-ups the local player dropped.
+func (gs *GameNode) SharePowerUpIds(spawned []int) {
+	v, _ := json.Marshal(spawned)
+	gs.MakeProposal(fmt.Sprintf("powerup_ids_%v", PlayerId), string(v))
+}
+
+// Get the ids of the power-ups dropped by any player.
+func (gs *GameNode) GetPowerUpIds() []int {
+	var ids []int
+	for id := range(gs.playerAddresses) {
+		idsEncoded, err := gs.GetValue(fmt.Sprintf("powerup_ids_%v", id))
+		if err != nil {
+			continue
+		}
+
+		var playerIds []int
+		json.Unmarshal([]byte(idsEncoded), &playerIds)
+		ids = append(ids, playerIds...)
+	}
+
+	return ids
+}
+
+// Get a power-up by id.
+func (gs *GameNode) GetPowerUp(id int) (*PowerUp, error) {
+	powerUpEncoded, err := gs.GetValue(fmt.Sprintf("powerup_%v", id))
+	if err != nil {
+		return nil, err
+	}
+
+	var x, y float64
+	var kind int
+	fmt.Sscanf(powerUpEncoded, "(%v,%v,%v)", &x, &y, &kind)
+
+	return NewPowerUp(id, PowerUpKind(kind), x, y), nil
+}
+
+// Ask the host for the power-ups the local player touched.
+func (gs *GameNode) SharePowerUpRequests(ids []int) {
+	v, _ := json.Marshal(ids)
+	_, err := gs.MakeProposal(fmt.Sprintf("powerup_requests_%v", PlayerId), string(v))
+	if err != nil {
+		println("Was not able to share the power-up requests for player", PlayerId)
+	}
+}
+
+// Get the power-ups each player asked for, by player id.
+func (gs *GameNode) GetPowerUpRequests() map[int][]int {
+	requests := make(map[int][]int)
+	for id := range(gs.playerAddresses) {
+		idsEncoded, err := gs.GetValue(fmt.Sprintf("powerup_requests_%v", id))
+		if err != nil {
+			continue
+		}
+
+		i, _ := strconv.Atoi(id)
+		var ids []int
+		json.Unmarshal([]byte(idsEncoded), &ids)
+		requests[i] = ids
+	}
+
+	return requests
+}
+
+// Give power-up id to player claimant, or mark it expired. Only the
+// host calls this, and a power-up that was already settled stays
+// with whoever got it. Fails if the power-up couldn't be settled.
+func (gs *GameNode) ClaimPowerUp(id, claimant int) error {
+	key := fmt.Sprintf("powerup_claim_%v", id)
+	if _, err := gs.GetValue(key); err == nil {
+		return nil
+	}
+	_, err := gs.MakeProposal(key, strconv.Itoa(claimant))
+	return err
+}
+
+// Who got power-up id, and whether the host has settled it yet.
+func (gs *GameNode) PowerUpClaim(id int) (int, bool) {
+	v, err := gs.GetValue(fmt.Sprintf("powerup_claim_%v", id))
+	if err != nil {
+		return 0, false
+	}
+
+	claimant, _ := strconv.Atoi(v)
+	return claimant, true
+}
+
 // Gets the hostports of all of the players registered with the game
 // server located at "server". 
 func (gs *GameNode) GetPlayerAddresses(server string) (map[string]string, error) {
-	client, err := rpc.DialHTTP("tcp", server)
+	// Get the value for player_addresses, located on the master.
+	encoded, err := gs.getServerValue(server, "player_addresses")
 	if err != nil {
 		return nil, err
-	} 
-
-	// Get the value for player_addresses, located on the master.
-	getArgs := &paxosrpc.GetValueArgs{
-		Key: "player_addresses",
 	}
-	getReply := new(paxosrpc.GetValueReply)
-	client.Call("PaxosNode.GetValue", getArgs, getReply)
 
 	// Decode the resulting map.
 	var vals map[string]string
-	json.Unmarshal([]byte(getReply.V.(string)), &vals)
-
-	client.Close()
+	json.Unmarshal([]byte(encoded), &vals)
 
 	return vals, nil
 }
 
+// Reads the value for key directly from the game server located
+// at "server", before this node has joined the paxos ring.
+func (gs *GameNode) getServerValue(server, key string) (string, error) {
+	client, err := rpc.DialHTTP("tcp", server)
+	if err != nil {
+		return "", err
+	} 
+	defer client.Close()
+
+	getArgs := &paxosrpc.GetValueArgs{
+		Key: key,
+	}
+	getReply := new(paxosrpc.GetValueReply)
+	err = client.Call("PaxosNode.GetValue", getArgs, getReply)
+	if err != nil {
+		return "", err
+	}
+	if getReply.Status == paxosrpc.KeyNotFound {
+		return "", errors.New("Could not find key")
+	}
+
+	return getReply.V.(string), nil
+}
+
 
@@ -0,0 +1,152 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+* License, v. 2.0. If a copy of the MPL was not distributed with this
+* file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package main
+
+import (
+	"math"
+
+	"github.com/go-gl/gl/v2.1/gl"
+	glfw "github.com/go-gl/glfw3/v3.0/glfw"
+)
+
+// Planet or black hole pulling ships, bullets and asteroids towards
+// it. Whatever hits its core is gone. Some wells orbit a point of the
+// field, which makes for moving slingshots.
+type GravityWell struct {
+	CenterX, CenterY float64 // Where it sits, or what it orbits.
+	Strength         float64 // Pull at a distance of 1, falling off with the square.
+	CoreRadius       float64
+	OrbitRadius      float64 // 0 for a well that stays put.
+	OrbitSpeed       float64 // Degrees per second.
+	OrbitAngle       float64
+	X, Y             float64 // Where it is now.
+}
+
+// Gravity well of a level file. Positions are fractions of the field
+// size, so levels fit any window.
+type wellSpec struct {
+	X, Y       float64
+	Strength   float64
+	Core       float64
+	Orbit      float64
+	OrbitSpeed float64
+}
+
+// Outside of this many core radii, wells are only drawn as a dotted
+// ring and not worth avoiding when spawning things.
+const wellReach = 6
+
+func NewGravityWell(x, y, strength, core, orbit, orbitSpeed float64) *GravityWell {
+	well := &GravityWell{x, y, strength, core, orbit, orbitSpeed, 0, x, y}
+	well.place()
+	return well
+}
+
+// Wells of the level file the host loaded, if any.
+func levelWells() []*GravityWell {
+	var wells []*GravityWell
+	if gameNode.Level != nil {
+		for _, spec := range gameNode.Level.Wells {
+			wells = append(wells, NewGravityWell(spec.X*gameWidth, spec.Y*gameHeight, spec.Strength, spec.Core, spec.Orbit, spec.OrbitSpeed))
+		}
+	}
+	return wells
+}
+
+func (well *GravityWell) place() {
+	rad := well.OrbitAngle * math.Pi / 180
+	well.X = well.CenterX + well.OrbitRadius*math.Sin(rad)
+	well.Y = well.CenterY + well.OrbitRadius*math.Cos(rad)
+}
+
+// Pulls ent towards the well for timediff.
+func (well *GravityWell) Pull(ent *Entity, timediff float64) {
+	dx, dy := wrappedOffset(ent.PosX, ent.PosY, well.X, well.Y)
+	d := math.Max(math.Hypot(dx, dy), well.CoreRadius)
+	accel := well.Strength / (d * d) * timediff
+	ent.VelocityX += dx / d * accel
+	ent.VelocityY += dy / d * accel
+}
+
+// Reports whether ent has hit the well's core.
+func (well *GravityWell) Swallows(ent *Entity) bool {
+	return wrappedDistance(ent.PosX, ent.PosY, well.X, well.Y) < well.CoreRadius+ent.Radius()/2
+}
+
+func (well *GravityWell) Draw() {
+	gl.Begin(gl.LINE_LOOP)
+	gl.Color3d(Colorize(0.9), Colorize(0.7), Colorize(0.3))
+	for i := 0; i < 24; i++ {
+		rad := float64(i) * math.Pi / 12
+		gl.Vertex2d(well.X+well.CoreRadius*math.Cos(rad), well.Y+well.CoreRadius*math.Sin(rad))
+	}
+	gl.End()
+
+	gl.Begin(gl.POINTS)
+	gl.Color3d(Colorize(0.4), Colorize(0.3), Colorize(0.15))
+	for i := 0; i < 48; i++ {
+		rad := float64(i) * math.Pi / 24
+		r := well.CoreRadius * wellReach
+		gl.Vertex2d(well.X+r*math.Cos(rad), well.Y+r*math.Sin(rad))
+	}
+	gl.End()
+}
+
+var lastGravityUpdate float64
+
+// Moves orbiting wells and pulls everything that flies towards the
+// wells.
+func updateGravity() {
+	timediff := (glfw.GetTime() - lastGravityUpdate) * 500
+	lastGravityUpdate = glfw.GetTime()
+	if paused {
+		return
+	}
+
+	for _, well := range wells {
+		well.OrbitAngle = math.Mod(well.OrbitAngle+well.OrbitSpeed*timediff/500, 360)
+		well.place()
+
+		for _, ships := range shipMap {
+			well.Pull(&ships.Entity, timediff)
+		}
+		for _, bullet := range bullets {
+			well.Pull(&bullet.Entity, timediff)
+		}
+		for _, torpedo := range torpedos {
+			well.Pull(&torpedo.Entity, timediff)
+		}
+		for _, asteroid := range asteroids {
+			well.Pull(&asteroid.Entity, timediff)
+		}
+	}
+}
+
+// Whatever hits a core is gone: ships are destroyed, asteroids are
+// swallowed whole without splitting. Other players' ships are left
+// to their own players.
+func gravityHitDetection() {
+	for _, well := range wells {
+		if ship.IsAlive() && well.Swallows(&ship.Entity) {
+			destroyShip(PlayerId, PlayerId)
+		}
+		for _, bullet := range bullets {
+			if bullet.IsAlive() && well.Swallows(&bullet.Entity) {
+				bullet.Destroy()
+			}
+		}
+		for _, torpedo := range torpedos {
+			if torpedo.IsAlive() && well.Swallows(&torpedo.Entity) {
+				torpedo.Destroy()
+			}
+		}
+		for _, asteroid := range asteroids {
+			if asteroid.IsAlive() && well.Swallows(&asteroid.Entity) {
+				asteroid.Entity.Destroy()
+				explosions = append(explosions, NewExplosion(asteroid.PosX, asteroid.PosY, asteroid.SizeRatio))
+			}
+		}
+	}
+}
@@ -0,0 +1,98 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+* License, v. 2.0. If a copy of the MPL was not distributed with this
+* file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package main
+
+import glfw "github.com/go-gl/glfw3/v3.0/glfw"
+
+// Hyperspace drive of a ship. After charging for ChargeTime the ship
+// jumps to a random spot, and the drive needs Cooldown seconds before
+// it can charge again. Every jump risks the ship, see
+// GameMode.HyperspaceRisk.
+type Hyperspace struct {
+	ChargeTime      float64
+	Cooldown        float64
+	Jumps           int // Jumps made so far, shared so peers can show them.
+	chargeTill      float64
+	readyTime       float64
+	lastUpdatedTime float64
+}
+
+func NewHyperspace() *Hyperspace {
+	return &Hyperspace{
+		ChargeTime:      0.6,
+		Cooldown:        5,
+		Jumps:           0,
+		chargeTill:      0,
+		readyTime:       0,
+		lastUpdatedTime: glfw.GetTime(),
+	}
+}
+
+func (hyper *Hyperspace) IsCharging() bool {
+	return hyper.chargeTill > 0
+}
+
+func (hyper *Hyperspace) IsReady() bool {
+	return !hyper.IsCharging() && glfw.GetTime() >= hyper.readyTime
+}
+
+// Starts charging a jump, if the drive is ready.
+func (hyper *Hyperspace) Engage() {
+	if hyper.IsReady() {
+		hyper.chargeTill = glfw.GetTime() + hyper.ChargeTime
+	}
+}
+
+// Advances the charge and reports whether the ship should jump now.
+func (hyper *Hyperspace) Update() bool {
+	timediff := glfw.GetTime() - hyper.lastUpdatedTime
+	hyper.lastUpdatedTime = glfw.GetTime()
+	if paused {
+		if hyper.IsCharging() {
+			hyper.chargeTill += timediff
+		}
+		hyper.readyTime += timediff
+		return false
+	}
+
+	if hyper.IsCharging() && glfw.GetTime() >= hyper.chargeTill {
+		hyper.chargeTill = 0
+		hyper.readyTime = glfw.GetTime() + hyper.Cooldown
+		hyper.Jumps += 1
+		return true
+	}
+	return false
+}
+
+// Moves the ship through hyperspace. Most jumps land on a clear spot,
+// but with a chance of mode.HyperspaceRisk the ship either lands
+// wherever it ends up or doesn't survive the trip.
+func (ship *Ship) jump() {
+	explosions = append(explosions, NewTeleportEffect(ship.PosX, ship.PosY, false))
+
+	risky := rng.Float64() < mode.HyperspaceRisk
+	if risky && rng.Float64() < 0.5 {
+		destroyShip(ship.PlayerId, ship.PlayerId)
+		return
+	}
+
+	var x, y float64
+	if risky {
+		x, y = rng.Float64()*gameWidth, rng.Float64()*gameHeight
+	} else {
+		x, y, _ = FindSpawnPoint(mode.SpawnRadius)
+	}
+	ship.PosX, ship.PosY = x, y
+	ship.VelocityX, ship.VelocityY = 0, 0
+
+	explosions = append(explosions, NewTeleportEffect(ship.PosX, ship.PosY, true))
+}
+
+// Shows a jump a peer made, moving its ship from one spot to the
+// other at once.
+func showRemoteJump(fromX, fromY, toX, toY float64) {
+	explosions = append(explosions, NewTeleportEffect(fromX, fromY, false))
+	explosions = append(explosions, NewTeleportEffect(toX, toY, true))
+}
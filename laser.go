@@ -0,0 +1,237 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+* License, v. 2.0. If a copy of the MPL was not distributed with this
+* file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package main
+
+import (
+	"math"
+
+	"github.com/go-gl/gl/v2.1/gl"
+	glfw "github.com/go-gl/glfw3/v3.0/glfw"
+)
+
+const (
+	laserRange       = 250.0
+	laserHeatPerShot = 0.12
+	laserCoolRate    = 0.35 // Heat lost per second.
+)
+
+// Laser shots on screen.
+var lasers []*LaserBeam
+
+// What is left of a laser shot on screen: a line from the ship's nose
+// to whatever it hit, fading out.
+type LaserBeam struct {
+	Entity
+	MaxLifetime float64
+}
+
+func NewLaserBeam(x, y, angle, length float64) *LaserBeam {
+	shape := Polygon{
+		[]Vector{
+			Vector{0, 0},
+			Vector{0, length},
+		},
+		[]Color{
+			Color{1, 0.3, 0.3},
+			Color{1, 0.6, 0.6},
+		},
+	}
+	return &LaserBeam{*NewEntity(shape, x, y, angle, 0, 0, 0, 0, 0), 0.2}
+}
+
+func (beam *LaserBeam) Update() {
+	if paused {
+		timediff := (glfw.GetTime() - beam.Entity.lastUpdatedTime)
+		beam.MaxLifetime = beam.MaxLifetime + timediff
+	}
+	beam.Entity.Update()
+}
+
+func (beam *LaserBeam) Draw() {
+	if !beam.IsAlive() {
+		return
+	}
+	fade := 1 - (glfw.GetTime()-beam.createdTime)/beam.MaxLifetime
+	gl.Begin(gl.LINES)
+	for v, _ := range beam.Shape.Vectors {
+		c := beam.Shape.Colors[v]
+		gl.Color3d(Colorize(c.R*fade), Colorize(c.G*fade), Colorize(c.B*fade))
+		beam.GlVertex2d(beam.Shape.Vectors[v])
+	}
+	gl.End()
+}
+
+func (beam *LaserBeam) IsAlive() bool {
+	if glfw.GetTime() > beam.createdTime+beam.MaxLifetime {
+		return false
+	}
+	return beam.Entity.IsAlive()
+}
+
+// Fires a ray from x, y in direction angle that stops at the first
+// asteroid, saucer, boss weak point or ship of an enemy of owner, and
+// damages it. Shielded ships and the boss's hull stop the beam without
+// taking damage.
+func castLaser(x, y, angle float64, owner int) {
+	length := laserRange
+	var hit func()
+	check := func(ent *Entity, onHit func()) {
+		if d, ok := RayIntersection(x, y, angle, length, ent); ok {
+			length, hit = d, onHit
+		}
+	}
+
+	for _, asteroid := range asteroids {
+		if asteroid.IsAlive() {
+			asteroid := asteroid
+			check(&asteroid.Entity, func() {
+				var rad float64 = ((angle) * math.Pi) / 180
+				impact := Impact{x + length*math.Sin(rad), y + length*math.Cos(rad),
+					bulletImpulse * math.Sin(rad), bulletImpulse * math.Cos(rad)}
+				if mode.Physics {
+					asteroid.Push(impact)
+				}
+				asteroid.Damage(laserDamage, owner, impact)
+			})
+		}
+	}
+	for _, saucer := range saucers {
+		if saucer.IsAlive() {
+			saucer := saucer
+			check(&saucer.Entity, func() { saucer.Destroy(owner) })
+		}
+	}
+	if boss != nil && boss.IsAlive() {
+		check(&boss.Entity, func() {})
+		for i, point := range boss.WeakPoints {
+			if point.IsAlive() {
+				i := i
+				check(&point.Entity, func() { boss.Damage(i, laserDamage, owner) })
+			}
+		}
+	}
+	for id, ships := range shipMap {
+		if id != owner && ships.IsAlive() && canHurt(owner, id) {
+			ships := ships
+			check(&ships.Entity, func() { ships.Damage(laserDamage, owner) })
+		}
+	}
+	for _, obstacle := range obstacles {
+		check(&obstacle.Entity, func() {})
+	}
+	if mode.Arena && wallDistance(x, y, angle) < length {
+		length, hit = wallDistance(x, y, angle), nil
+	}
+
+	if hit != nil {
+		hit()
+	}
+	lasers = append(lasers, NewLaserBeam(x, y, angle, length))
+}
+
+// Instant-hit beam weapon. It needs no ammo but heats up with every
+// shot; once overheated it won't fire until it has cooled down.
+type Laser struct {
+	heat            float64
+	overheated      bool
+	lastUpdatedTime float64
+}
+
+func init() {
+	RegisterWeapon(3, func() Weapon { return &Laser{0, false, glfw.GetTime()} })
+	RegisterProjectiles(laserBeams{})
+}
+
+// The laser shots on screen, for the game loop.
+type laserBeams struct{}
+
+func (laserBeams) Update() {
+	var alive []*LaserBeam
+	for _, beam := range lasers {
+		if beam.IsAlive() {
+			alive = append(alive, beam)
+		}
+	}
+	lasers = alive
+	for _, beam := range lasers {
+		beam.Update()
+	}
+}
+
+func (laserBeams) Draw() {
+	for _, beam := range lasers {
+		beam.Draw()
+	}
+}
+
+func (laserBeams) Reset() {
+	lasers = nil
+}
+
+func (laser *Laser) Name() string {
+	return "laser"
+}
+
+func (laser *Laser) Cooldown(ship *Ship) float64 {
+	if ship.HasEffect(PowerUpRapidFire) {
+		return 0.08
+	}
+	return 0.15
+}
+
+func (laser *Laser) Spread(ship *Ship) []float64 {
+	if ship.HasEffect(PowerUpSpreadShot) {
+		return []float64{0, -15, 15}
+	}
+	return []float64{0}
+}
+
+func (laser *Laser) Launch(ship *Ship, angle float64) {
+	x, y := RotateVector(&Vector{0, 5}, angle)
+	castLaser(ship.PosX+x, ship.PosY+y, angle, ship.PlayerId)
+}
+
+func (laser *Laser) Automatic() bool {
+	return true
+}
+
+func (laser *Laser) cool() {
+	if !paused {
+		laser.heat -= (glfw.GetTime() - laser.lastUpdatedTime) * laserCoolRate
+		if laser.heat <= 0 {
+			laser.heat = 0
+			laser.overheated = false
+		}
+	}
+	laser.lastUpdatedTime = glfw.GetTime()
+}
+
+// No shots while overheated, unlimited otherwise.
+func (laser *Laser) Ammo() int {
+	laser.cool()
+	if laser.overheated {
+		return 0
+	}
+	return -1
+}
+
+func (laser *Laser) UseAmmo() {
+	laser.cool()
+	laser.heat += laserHeatPerShot
+	if laser.heat >= 1 {
+		laser.heat = 1
+		laser.overheated = true
+	}
+}
+
+func (laser *Laser) Refill() {
+	laser.heat = 0
+	laser.overheated = false
+}
+
+func (laser *Laser) Heat() float64 {
+	laser.cool()
+	return laser.heat
+}
@@ -0,0 +1,86 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+* License, v. 2.0. If a copy of the MPL was not distributed with this
+* file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package main
+
+import (
+	"fmt"
+
+	glfw "github.com/go-gl/glfw3/v3.0/glfw"
+)
+
+var (
+	lives         int     // Ships left for this player, including the current one.
+	nextExtraLife int     // Score at which the next extra life is granted.
+	lifeIcon      *Entity // Ship drawn for each life, built on first use.
+)
+
+// Starts a new game with a full set of lives.
+func resetLives() {
+	lives = mode.Lives
+	nextExtraLife = mode.ExtraLifeScore
+}
+
+func hasLivesLeft() bool {
+	return mode.Lives == 0 || lives > 0
+}
+
+// Grants an extra life for every ExtraLifeScore points scored.
+func checkExtraLife() {
+	if mode.Lives == 0 || mode.ExtraLifeScore <= 0 {
+		return
+	}
+	for score >= nextExtraLife {
+		lives += 1
+		nextExtraLife += mode.ExtraLifeScore
+	}
+}
+
+// Takes a life when the local ship is destroyed and brings the ship
+// back once the respawn delay has passed and a clear spawn point
+// turns up, preferably the center of the field.
+func updateRespawn() {
+	if ship.IsAlive() || isMatchOver() {
+		respawnTime = 0
+		return
+	}
+	if respawnTime == 0 {
+		if mode.Lives > 0 && lives > 0 {
+			lives -= 1
+		}
+		respawnTime = glfw.GetTime() + mode.RespawnDelay
+	} else if glfw.GetTime() >= respawnTime && hasLivesLeft() {
+		x, y, ok := FindSpawnPoint(mode.SpawnRadius, Vector{gameWidth / 2, gameHeight / 2})
+		if ok {
+			ship = NewShip(PlayerId, x, y, 0, 0.01)
+			shipMap[PlayerId] = ship
+			respawnTime = 0
+		}
+	}
+}
+
+// Draws the remaining lives as ship icons below the score.
+func drawLives() {
+	if mode.Lives == 0 {
+		return
+	}
+	if lifeIcon == nil {
+		lifeIcon = NewEntity(shipShape(PlayerId), 0, 0, 0, 0, 0, 0, 0, 0)
+	}
+	for i := 0; i < lives; i++ {
+		lifeIcon.PosX = 14 + float64(i)*12
+		lifeIcon.PosY = fieldSize - 44
+		lifeIcon.Draw(false)
+	}
+}
+
+func drawRespawnCountdown() {
+	if !ship.IsAlive() && respawnTime > 0 && hasLivesLeft() && !isMatchOver() {
+		left := int(respawnTime-glfw.GetTime()) + 1
+		if left < 1 {
+			left = 1
+		}
+		DrawString(fieldSize/2-60, fieldSize/2, 1.5, Color{1, 1, 1}, fmt.Sprintf("respawn in %d", left))
+	}
+}
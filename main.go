@@ -10,6 +10,7 @@ import (
 	"flag"
 	"math/rand"
 	"runtime"
+	"strings"
 	"time"
 	"log"
 
@@ -27,10 +28,28 @@ var (
 	bullets        []*Bullet
 	torpedos       []*Torpedo
 	mines          []*Mine
+	damageNumbers  []*DamageNumber
 	asteroids      map[int]*Asteroid
 	AsteroidCounter int
+	saucers        map[int]*Saucer
+	SaucerCounter  int
+	saucerKills    map[int]bool // Saucers this player shot down, for the host.
+	nextSaucerTime float64
+	lastSaucerCheck float64
 	explosions     []*Explosion
 	bigExplosions  []*BigExplosion
+	powerUps       map[int]*PowerUp
+	spawnedPowerUps []int // Power-ups this player dropped.
+	settledPowerUps map[int]bool // Power-ups collected or expired.
+	requestedPowerUps []int // Power-ups this player asked the host for.
+	pendingPowerUps map[int]PowerUpKind // Requested power-ups not applied to the local ship yet.
+	claimedPowerUps map[int]bool // Power-up requests the host has settled.
+	starfield      *Starfield
+	boss           *Boss
+	wells          []*GravityWell
+	obstacles      []*Obstacle
+	portals        []*PortalPair
+	bossDamage     []int // Damage this player did to each of the boss's weak points.
 	gameWidth      float64
 	gameHeight     float64
 	fieldSize      float64 = 400
@@ -46,6 +65,16 @@ var (
 	difficulty     int     = 6
 	debug          bool    = true
 	gameNode	   *GameNode
+	mode           GameMode // Rules of the current game, picked by the host.
+	players        map[int]PlayerInfo // What each player shared when joining.
+	frags          map[int]map[int]int // Frags of all players, by attacker and victim.
+	kills          map[int]int // Ships destroyed by this player, by victim.
+	killedBy       map[int]int // Frags other players scored on this player, by attacker.
+	hits           map[int]int // Damage this player did to other ships, by victim.
+	hitsTaken      map[int]int // Damage done to this player already handled, by attacker.
+	respawnTime    float64
+	matchStartTime float64 // Seconds since the Unix epoch, the same for every player.
+	matchEpoch     int // Number of the match being played, the host counts it up.
 )
 
 func errorCallback(err glfw.ErrorCode, desc string) {
@@ -56,6 +85,24 @@ func main() {
     host := flag.String("server", "", "the host:port of the game server")
     myHostPort := flag.String("hostAt", "", "port at which to start a game server")
    	clientPort := flag.String("myNodeAt", "", "port at which to start game client on")
+	modeName := flag.String("mode", "classic", "game mode to host: classic, deathmatch, team or arena")
+	fragLimit := flag.Int("fragLimit", -1, "frags needed to win a match, 0 for no limit (default depends on mode)")
+	timeLimit := flag.Float64("timeLimit", -1, "match length in seconds, 0 for no limit (default depends on mode)")
+	startLives := flag.Int("lives", -1, "ships per player, 0 for unlimited (default depends on mode)")
+	hyperspaceRisk := flag.Float64("hyperspaceRisk", -1, "chance a hyperspace jump goes wrong, 0 to 1 (default depends on mode)")
+	extraLife := flag.Int("extraLife", -1, "points needed for an extra life, 0 for none (default depends on mode)")
+	spawnRadius := flag.Float64("spawnRadius", -1, "room to keep around new ships and asteroids (default depends on mode)")
+	friendlyFire := flag.Bool("friendlyFire", false, "whether projectiles can hit teammates (default depends on mode)")
+	physics := flag.Bool("physics", false, "whether things bounce off and push each other (default depends on mode)")
+	split := flag.String("split", "", "how asteroids split: random or physics (default depends on mode)")
+	fracture := flag.Bool("fracture", false, "whether asteroids break into shards along the shot")
+	worldWidth := flag.Float64("worldWidth", 0, "width of the field, the screen being 400 high; 0 to fit each player's window")
+	worldHeight := flag.Float64("worldHeight", 0, "height of the field; 0 to fit each player's window")
+	startZoom := flag.Float64("zoom", 1, "how far the camera is zoomed in, change it with + and -")
+	arena := flag.Bool("arena", false, "whether the field has walls instead of wrapping around (default depends on mode)")
+	levelFile := flag.String("level", "", "file with the obstacles and gravity wells to play with")
+	team := flag.Int("team", -1, "team to join in team games, -1 to be assigned one")
+	name := flag.String("name", "", "name the other players see you as")
     flag.Parse()
 
     // Complain if flags weren't set.
@@ -65,6 +112,60 @@ func main() {
     	log.Fatal("You must specify a local port to host your client on with the -myNodeAt flag")
     }
 
+	// Rules for the game we host. Clients get them from the host.
+	hostMode, err := GetGameMode(*modeName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *fragLimit >= 0 {
+		hostMode.FragLimit = *fragLimit
+	}
+	if *timeLimit >= 0 {
+		hostMode.TimeLimit = *timeLimit
+	}
+	if *startLives >= 0 {
+		hostMode.Lives = *startLives
+	}
+	if *extraLife >= 0 {
+		hostMode.ExtraLifeScore = *extraLife
+	}
+	if *hyperspaceRisk >= 0 {
+		hostMode.HyperspaceRisk = *hyperspaceRisk
+	}
+	if *spawnRadius >= 0 {
+		hostMode.SpawnRadius = *spawnRadius
+	}
+	hostMode.WorldWidth = *worldWidth
+	hostMode.WorldHeight = *worldHeight
+	setZoom(*startZoom)
+	if *split != "" {
+		if _, err := GetSplitRule(*split); err != nil {
+			log.Fatal(err)
+		}
+		hostMode.SplitRule = *split
+	}
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "friendlyFire" {
+			hostMode.FriendlyFire = *friendlyFire
+		}
+		if f.Name == "physics" {
+			hostMode.Physics = *physics
+		}
+		if f.Name == "fracture" {
+			hostMode.Fracture = *fracture
+		}
+		if f.Name == "arena" {
+			hostMode.Arena = *arena
+		}
+	})
+	var hostLevel *Level
+	if *levelFile != "" {
+		hostLevel, err = LoadLevel(*levelFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
 	runtime.LockOSThread()
 	glfw.SetErrorCallback(errorCallback)
 
@@ -77,28 +178,37 @@ func main() {
  	isClient = *host != ""
 
 	var window *glfw.Window
-	window, err := initWindow()
+	window, err = initWindow()
 	if err != nil {
 		panic(err)
 	}
 
 	// Attempt to construct GameNode.
     if isClient {
-    	gn, err := NewGameClient(*clientPort, *host)
+    	gn, err := NewGameClient(*clientPort, *host, cleanName(*name))
     	gameNode = gn
     	if err != nil {
     		panic("Could not make game client")
     	}
     } else {
-    	gn, err := NewGameServer(*myHostPort)
+    	gn, err := NewGameServer(*myHostPort, hostMode, hostLevel, cleanName(*name))
     	gameNode = gn
     	if err != nil {
     		panic("Could not start game server")
     	} 
     }
 	PlayerId = gameNode.PlayerId    
+	mode = gameNode.Mode
+	resizeWorld()
+
+	// Tell the other players about ourselves.
+	players = gameNode.GetPlayerInfos()
+	players[PlayerId] = PlayerInfo{Team: pickTeam(*team, players)}
+	gameNode.SharePlayerInfo(players[PlayerId])
 
 	// Initializes data structures.
+	starfield = NewStarfield(starfieldSeed)
+	resetLives()
     resetGame(!isClient)
 
     // Start the main game loop.
@@ -113,8 +223,8 @@ func keyCallback(window *glfw.Window, key glfw.Key, scancode int, action glfw.Ac
 		r := rand.New(rand.NewSource(time.Now().UnixNano()))
 		x:=r.Float64()
 		y:=r.Float64()
-		shipNew := NewShip(gameWidth/x, gameHeight/y, 0, 0.01)
 		shipId += 1
+		shipNew := NewShip(shipId, gameWidth/x, gameHeight/y, 0, 0.01)
 		shipMap[shipId]=shipNew
 	}
 
@@ -160,12 +270,34 @@ func keyCallback(window *glfw.Window, key glfw.Key, scancode int, action glfw.Ac
 			}
 		}
 
+		if key == glfw.KeyQ && action == glfw.Press {
+			ship.PreviousWeapon()
+		} else if key == glfw.KeyW && action == glfw.Press {
+			ship.NextWeapon()
+		}
+
+		if key == glfw.KeyL && action == glfw.Press {
+			ship.LockTarget()
+		}
+
+		if key == glfw.KeyH && action == glfw.Press {
+			ship.Hyperjump()
+		}
+
+		if key == glfw.KeyS {
+			if action == glfw.Press {
+				ship.RaiseShield(true)
+			} else if action == glfw.Release {
+				ship.RaiseShield(false)
+			}
+		}
+
 		if (key == glfw.KeyY || key == glfw.KeyZ || key == glfw.KeyLeftShift || key == glfw.KeyRightShift) && action == glfw.Press {
-			ship.DropMine()
+			ship.FireWeapon("mines")
 		}
 
 		if (key == glfw.KeyC || key == glfw.KeyLeftControl || key == glfw.KeyRightControl) && action == glfw.Press {
-			ship.ShootTorpedo()
+			ship.FireWeapon("torpedo")
 		}
 	}
 
@@ -187,14 +319,22 @@ func keyCallback(window *glfw.Window, key glfw.Key, scancode int, action glfw.Ac
 
 	if (key == glfw.KeyF9 || key == glfw.KeyR || key == glfw.KeyBackspace) && action == glfw.Press {
 		score = 0
+		resetLives()
 		resetGame(!isClient)
 	}
 
+	if (key == glfw.KeyEqual || key == glfw.KeyKpAdd) && action == glfw.Press {
+		zoomIn()
+	} else if (key == glfw.KeyMinus || key == glfw.KeyKpSubtract) && action == glfw.Press {
+		zoomOut()
+	}
+
 	if (key == glfw.KeyPause || key == glfw.KeyP) && action == glfw.Press {
 		paused = !paused
 	}
 
 	if key == glfw.KeyN && action == glfw.Press && isGameWon() {
+		level += 1
 		difficulty += 3
 		resetGame(!isClient)
 	}
@@ -215,14 +355,10 @@ func keyCallback(window *glfw.Window, key glfw.Key, scancode int, action glfw.Ac
 
 func reshapeWindow(window *glfw.Window, width, height int) {
 	ratio := float64(width) / float64(height)
-	gameWidth = ratio * fieldSize
-	gameHeight = fieldSize
+	screenWidth = ratio * fieldSize
+	resizeWorld()
 	gl.Viewport(0, 0, int32(width), int32(height))
-	gl.MatrixMode(gl.PROJECTION)
-	gl.LoadIdentity()
-
-	gl.Ortho(0, gameWidth, 0, gameHeight, -1.0, 1.0)
-	gl.MatrixMode(gl.MODELVIEW)
+	hudProjection()
 	if wireframe {
 		gl.PolygonMode(gl.FRONT_AND_BACK, gl.LINE)
 	}
@@ -302,12 +438,23 @@ func switchWireframe() {
 	}
 }
 
+const maxNameLength = 12
+
+// Trims a player name to something short enough for a ship label.
+func cleanName(name string) string {
+	name = strings.TrimSpace(name)
+	if runes := []rune(name); len(runes) > maxNameLength {
+		name = string(runes[:maxNameLength])
+	}
+	return name
+}
+
 func isGameWon() bool {
-	return len(asteroids) == 0 && len(shipMap)>0
+	return !mode.PvP && len(asteroids) == 0 && len(shipMap)>0 && (boss == nil || !boss.IsAlive())
 }
 
 func isGameLost() bool {
-	return len(shipMap)==0
+	return !hasLivesLeft() && len(shipMap)==0
 }
 
 /* BEGIN CUSTOM CODE */
@@ -326,29 +473,81 @@ func resetGame(generateAsteroids bool) {
 	// Init ship.
 	shipId=PlayerId
 
-	// Create ship/player map.
+	// Create ship/player map, holding the other players we know of
+	// so we don't spawn on top of them.
 	shipMap=make(map[int]*Ship)
+	updatePlayers()
+	delete(shipMap, PlayerId)
+
+	asteroids = make(map[int]*Asteroid)
+	saucers = make(map[int]*Saucer)
+	// Power-ups still on screen may be listed by players who haven't
+	// reset yet, so keep them from coming back. The ones already
+	// gone were settled and won't come back anyway.
+	settledPowerUps = make(map[int]bool)
+	for id := range powerUps {
+		settledPowerUps[id] = true
+	}
+	powerUps = make(map[int]*PowerUp)
+	pendingPowerUps = make(map[int]PowerUpKind)
+	claimedPowerUps = make(map[int]bool)
+	spawnedPowerUps = nil
+	requestedPowerUps = nil
+	gameNode.SharePowerUpIds(spawnedPowerUps)
+	gameNode.SharePowerUpRequests(requestedPowerUps)
+	saucerKills = make(map[int]bool)
+	nextSaucerTime = 0
+	boss = nil
+	bossDamage = nil
+	mines = nil
+	if !isClient {
+		wells = levelWells()
+		portals = levelPortals()
+		gameNode.ShareGameLevel(level)
+	}
+	lastGravityUpdate = glfw.GetTime()
+	obstacles = levelObstacles()
 
-	// Create new ship.
-	ship = NewShip(gameWidth/2,gameHeight/2, 0, 0.01)
+	// Create new ship, in the center unless another player is there.
+	x, y, _ := FindSpawnPoint(mode.SpawnRadius, Vector{gameWidth/2, gameHeight/2})
+	ship = NewShip(PlayerId, x, y, 0, 0.01)
 	
 	// Add to player list.
 	shipMap[shipId] = ship
-
-	asteroids = make(map[int]*Asteroid)
+	camera.X, camera.Y = ship.PosX, ship.PosY
 
 	if generateAsteroids {
-		// Create a couple of random asteroids
-		for i := 1; i <= difficulty; i++ {
+		// Create a couple of random asteroids, fewer when a
+		// mothership comes along.
+		count := difficulty
+		if isBossLevel() && !mode.PvP {
+			count = difficulty / 2
+			spawnBoss()
+		}
+		for i := 1; i <= count; i++ {
 			CreateAsteroid(2+rng.Float64()*8, 3)
 		}
 	}
 
 	bullets = nil
-	mines = nil
+	resetProjectiles()
+	damageNumbers = nil
+	damageDealt = 0
 	explosions = nil
 	torpedos = nil
 	bigExplosions = nil
+
+	// The host starts a new match, everyone else stays in the
+	// running one or asks for a new one once it is over.
+	respawnTime = 0
+	if !isClient {
+		startMatch()
+	} else {
+		if mode.PvP && isMatchOver() {
+			gameNode.ShareMatchRequest(matchEpoch)
+		}
+		joinMatch()
+	}
 }
 
 // Share's current user information such as player position
@@ -369,7 +568,7 @@ func updateAsteroids() {
 		if !ok && v.Lives > 0 {
 			// New asteroid.
 			asteroid = NewAsteroid(v.PosX, v.PosY, v.Angle, v.TurnRate, 
-				v.VelocityX, v.VelocityY, v.SizeRatio, v.Lives)
+				v.VelocityX, v.VelocityY, v.SizeRatio, v.Lives, v.Seed, v.Material)
 			asteroid.Id = i
 			asteroids[i] = asteroid
 		} else if v.Lives > 0 {
@@ -383,9 +582,19 @@ func updateAsteroids() {
 			asteroids[i].SizeRatio = v.SizeRatio
 			asteroids[i].AccelerationRate = v.AccelerationRate
 			asteroids[i].Lives = v.Lives
+			asteroids[i].Health = v.Health
 		} else if v.Lives == 0 {
 			// Delete asteroid.
 			delete(asteroids, i)
+			continue
+		}
+
+		// A shard can show up before how it was cut is decided, so
+		// keep looking until it is.
+		if v.shard && asteroids[i] != nil && asteroids[i].Fracture == nil {
+			if fracture := gameNode.GetAsteroidFracture(i); fracture != nil {
+				asteroids[i].SetFracture(fracture)
+			}
 		}
 	}
 }
@@ -394,14 +603,28 @@ func updateAsteroids() {
 // state to reflect this.
 func updatePlayers(){
 	paxosShips:=gameNode.GetPlayers()
+
+	// Our own info is authoritative locally, even if sharing it failed.
+	infos := gameNode.GetPlayerInfos()
+	infos[PlayerId] = players[PlayerId]
+	players = infos
+
 	for shipId, ship := range paxosShips {
 		existingShip, ok:=shipMap[shipId]
     	if ok && !ship.IsAlive() {
     		// Existing player died.
     		existingShip.Destroy()
     		delete(shipMap,shipId)
+    		if mode.PvP && shipId != PlayerId && ship.LastHitBy == PlayerId {
+    			registerFrag(shipId)
+    		}
     	} else if ok {
-    		// Existing playe update.
+    		// Existing player update.
+    		if shipId != PlayerId && ship.Hyperspace.Jumps != existingShip.Hyperspace.Jumps {
+    			// Jumped through hyperspace, don't let it look like a flight.
+    			showRemoteJump(existingShip.PosX, existingShip.PosY, ship.PosX, ship.PosY)
+    			existingShip.Hyperspace.Jumps = ship.Hyperspace.Jumps
+    		}
     		shipMap[shipId].PosX=ship.PosX
     		shipMap[shipId].PosY=ship.PosY
     		shipMap[shipId].Angle=ship.Angle
@@ -409,9 +632,15 @@ func updatePlayers(){
     		shipMap[shipId].VelocityY=ship.VelocityY
     		shipMap[shipId].TurnRate=ship.TurnRate
     		shipMap[shipId].AccelerationRate=ship.AccelerationRate
+    		shipMap[shipId].Shape.Colors[0]=shipColor(shipId)
+    		if shipId != PlayerId {
+    			// Our own shield and hull are only ever changed locally.
+    			shipMap[shipId].Shield=ship.Shield
+    			shipMap[shipId].Hull=ship.Hull
+    		}
     	} else if ship.IsAlive() {
     		// New player added.
-			shipMap[shipId] = NewShip(gameWidth/2, gameHeight/2, 0, 0.01)
+			shipMap[shipId] = NewShip(shipId, gameWidth/2, gameHeight/2, 0, 0.01)
     		shipMap[shipId].PosX=ship.PosX
     		shipMap[shipId].PosY=ship.PosY
     		shipMap[shipId].Angle=ship.Angle
@@ -419,6 +648,9 @@ func updatePlayers(){
     		shipMap[shipId].VelocityY=ship.VelocityY
     		shipMap[shipId].TurnRate=ship.TurnRate
     		shipMap[shipId].AccelerationRate=ship.AccelerationRate
+    		shipMap[shipId].Shield=ship.Shield
+    		shipMap[shipId].Hyperspace=ship.Hyperspace
+    		shipMap[shipId].Hull=ship.Hull
     	}
 	}
 }
@@ -426,31 +658,70 @@ func updatePlayers(){
 // Main game loop of code. Called once per game step.
 func runGameLoop(window *glfw.Window) {
 	for !window.ShouldClose() {
+		updateGravity()
 		updateObjects()
+		passPortals()
 		hitDetection()
+		if mode.Physics {
+			updatePhysics()
+		}
 
 		// Upload data to Paxos.
 		shareGameState()
 		// Pull data from Paxos.
 		updateAsteroids()
 		updatePlayers()
+		updateSaucers()
+		updateBoss()
+		updateLevel()
+		updateLevelObjects()
+		updatePowerUps()
+		if !isClient {
+			updateSaucerSpawns()
+		}
+		if mode.PvP {
+			updateMatch()
+			updateFrags()
+			updateHits()
+		}
+		updateRespawn()
 
 		// ---------------------------------------------------------------
 		// draw calls
 		gl.Clear(gl.COLOR_BUFFER_BIT)
+		hudProjection()
+
+		starfield.Update()
+		starfield.Draw()
 
 		drawCurrentScore()
 		drawHighScore()
+		drawLives()
+		drawShieldBar()
+		drawHullBar()
+		drawBossBar()
+		drawWeapon()
+		drawRespawnCountdown()
+
+		if mode.PvP {
+			drawScoreboard()
+		}
 
 		if isGameWon() {
 			drawWinningScreen()
 		} else if isGameLost() {
 			drawGameOverScreen()
+		} else if isMatchOver() {
+			drawMatchOverScreen()
 		}
 
-		// draw everything 9 times in a 3x3 grid stitched together for seamless clipping
-		for x := -1.0; x < 2.0; x++ {
-			for y := -1.0; y < 2.0; y++ {
+		// draw the copies of the field around it the camera can see stitched together
+		// for seamless clipping, or just the field in a bounded arena
+		camera.Update()
+		camera.Apply()
+		minX, maxX, minY, maxY := camera.VisibleCopies()
+		for x := minX; x <= maxX; x++ {
+			for y := minY; y <= maxY; y++ {
 				gl.MatrixMode(gl.MODELVIEW)
 				gl.PushMatrix()
 				gl.Translated(gameWidth*x, gameHeight*y, 0)
@@ -460,6 +731,9 @@ func runGameLoop(window *glfw.Window) {
 				gl.PopMatrix()
 			}
 		}
+		if mode.Arena {
+			drawWalls()
+		}
 
 		gl.Flush()
 		window.SwapBuffers()
@@ -499,30 +773,38 @@ func drawHighScore() {
 
 func drawCurrentScore() {
 	DrawString(10, fieldSize-20, 1, Color{1, 1, 1}, fmt.Sprintf("score: %d", score))
+	DrawString(110, fieldSize-20, 1, Color{0.7, 0.6, 0}, fmt.Sprintf("damage: %d", damageDealt))
 }
 
 func drawWinningScreen() {
 	DrawString(fieldSize/2-20, fieldSize/2+10, 5, Color{1, 1, 1}, fmt.Sprintf("You won!"))
+	DrawString(fieldSize/2-120, fieldSize/2+60, 1.5, shipColor(PlayerId), fmt.Sprintf("Well done %s!", gameNode.PlayerName(PlayerId)))
 	DrawString(fieldSize/2-120, fieldSize/2-20, 1.5, Color{1, 1, 1}, fmt.Sprintf("Press R to restart current level"))
 	DrawString(fieldSize/2-120, fieldSize/2-50, 1.5, Color{1, 1, 1}, fmt.Sprintf("Press N to advance to next difficulty level"))
+	if (level+1)%bossLevelEvery == 0 {
+		DrawString(fieldSize/2-120, fieldSize/2-80, 1.5, Color{0.8, 0.6, 1}, fmt.Sprintf("A mothership awaits you there!"))
+	}
 }
 
 func drawGameOverScreen() {
 	DrawString(fieldSize/2-20, fieldSize/2+10, 5, Color{1, 1, 1}, fmt.Sprintf("Game Over!"))
+	DrawString(fieldSize/2-120, fieldSize/2+60, 1.5, shipColor(PlayerId), fmt.Sprintf("%s scored %d points", gameNode.PlayerName(PlayerId), score))
 	DrawString(fieldSize/2-120, fieldSize/2-20, 1.5, Color{1, 1, 1}, fmt.Sprintf("Press R to restart current level"))
 }
 
 func addScore(value int) {
 	if ship.IsAlive() {
 		score = score + value
+		checkExtraLife()
 	}
 };
 
 
 func drawObjects() {
 
-	for _,ships:=range shipMap{
+	for i,ships:=range shipMap{
 		ships.Draw(false)
+		drawShipLabel(i, ships)
 	}
 
 	for _, bullet := range bullets {
@@ -534,15 +816,45 @@ func drawObjects() {
 	for _, mine := range mines {
 		mine.Draw(false)
 	}
+	drawProjectiles()
 	for _, asteroid := range asteroids {
 		asteroid.Draw(true)
 	}
+	for _, well := range wells {
+		well.Draw()
+	}
+	for _, obstacle := range obstacles {
+		obstacle.Draw()
+	}
+	for _, pair := range portals {
+		pair.Draw()
+	}
+	for _, saucer := range saucers {
+		saucer.Draw(false)
+	}
+	if boss != nil {
+		boss.Draw()
+	}
+	for _, powerUp := range powerUps {
+		powerUp.Draw()
+	}
 	for _, explosion := range explosions {
 		explosion.Draw()
 	}
+	for _, number := range damageNumbers {
+		number.Draw()
+	}
 	for _, bigExplosion := range bigExplosions {
 		bigExplosion.Draw(false)
 	}
+	drawReticle()
+}
+
+// Draws the name of player id next to its ship.
+func drawShipLabel(id int, ship *Ship) {
+	if ship.IsAlive() {
+		DrawString(ship.PosX+8, ship.PosY+6, 0.6, shipColor(id), gameNode.PlayerName(id))
+	}
 }
 
 func updateObjects() {
@@ -579,6 +891,22 @@ func updateObjects() {
 	}
 	asteroids = asteroids2
 
+	saucers2 := make(map[int]*Saucer)
+	for _, saucer := range saucers {
+		if saucer.IsAlive() {
+			saucers2[saucer.Id] = saucer
+		}
+	}
+	saucers = saucers2
+
+	powerUps2 := make(map[int]*PowerUp)
+	for _, powerUp := range powerUps {
+		if powerUp.IsAlive() {
+			powerUps2[powerUp.Id] = powerUp
+		}
+	}
+	powerUps = powerUps2
+
 	var explosions2 []*Explosion
 	for _, explosion := range explosions {
 		if explosion.IsAlive() {
@@ -587,6 +915,14 @@ func updateObjects() {
 	}
 	explosions = explosions2
 
+	var damageNumbers2 []*DamageNumber
+	for _, number := range damageNumbers {
+		if number.IsAlive() {
+			damageNumbers2 = append(damageNumbers2, number)
+		}
+	}
+	damageNumbers = damageNumbers2
+
 	var bigExplosions2 []*BigExplosion
 	for _, bigExplosion := range bigExplosions {
 		if bigExplosion.IsAlive() {
@@ -608,12 +944,25 @@ func updateObjects() {
 	for _, mine := range mines {
 		mine.Update()
 	}
+	updateProjectiles()
 	for _, asteroid := range asteroids {
 		asteroid.Update()
 	}
+	for _, saucer := range saucers {
+		saucer.Update()
+	}
+	if boss != nil {
+		boss.Update()
+	}
+	for _, powerUp := range powerUps {
+		powerUp.Update()
+	}
 	for _, explosion := range explosions {
 		explosion.Update()
 	}
+	for _, number := range damageNumbers {
+		number.Update()
+	}
 	for _, bigExplosion := range bigExplosions {
 		bigExplosion.Update()
 	}
@@ -622,34 +971,46 @@ func updateObjects() {
 func hitDetection() {
 	for _, asteroid := range asteroids {
 		for _, bullet := range bullets {
-			if IsColliding(&asteroid.Entity, &bullet.Entity) {
-				asteroid.Destroy()
+			if bullet.IsAlive() && bullet.Owner != saucerOwner && IsColliding(&asteroid.Entity, &bullet.Entity) {
+				impact := impactOf(&bullet.Entity, bulletImpulse)
+				if mode.Physics {
+					asteroid.Push(impact)
+				}
+				asteroid.Damage(bulletDamage, bullet.Owner, impact)
 				bullet.Destroy()
 			}
 		}
-		// for _, torpedo := range torpedos {
-		// 	if IsColliding(&asteroid.Entity, &torpedo.Entity) {
-		// 		asteroid.Destroy()
-		// 		torpedo.Destroy()
-		// 	}
-		// }
+		for _, torpedo := range torpedos {
+			if torpedo.IsAlive() && IsColliding(&asteroid.Entity, &torpedo.Entity) {
+				impact := impactOf(&torpedo.Entity, bulletImpulse)
+				if mode.Physics {
+					asteroid.Push(impact)
+				}
+				asteroid.Damage(torpedoDamage, torpedo.Owner, impact)
+				torpedo.Destroy()
+			}
+		}
 		for _, mine := range mines {
-			if IsColliding(&asteroid.Entity, &mine.Entity) {
-				asteroid.Destroy()
+			if mine.InRange(&asteroid.Entity) {
 				mine.Destroy()
 			}
 		}
 		for _, bigExplosion := range bigExplosions {
-			if IsColliding(&asteroid.Entity, &bigExplosion.Entity) {
-				asteroid.Destroy()
+			if damage := bigExplosion.DamageTo(&asteroid.Entity); damage > 0 {
+				impact := bigExplosion.ImpactOn(&asteroid.Entity)
+				if mode.Physics {
+					asteroid.Push(impact)
+				}
+				asteroid.Damage(damage, bigExplosion.Owner, impact)
 			}
 		}
 		for i,ships:=range shipMap{
-			if ships.IsAlive() && IsColliding(&asteroid.Entity, &ships.Entity) {
-				asteroid.Destroy()
-				ships.Destroy()
-				delete(shipMap,i)
-				//Ships= append(Ships[:i], Ships[i+1:]...)
+			if ships.CanBeHit() && IsColliding(&asteroid.Entity, &ships.Entity) {
+				if mode.Physics {
+					bounce(&asteroid.Entity, &ships.Entity)
+				}
+				asteroid.Damage(collisionDamage, noOwner, ramImpact(&ships.Entity))
+				ships.Damage(collisionDamage, i)
 			}
 		}	
 	}
@@ -662,17 +1023,105 @@ func hitDetection() {
 	for _, bigExplosion := range bigExplosions {
 
 		for i,ships:=range shipMap{
-			if ships.IsAlive() && IsColliding(&bigExplosion.Entity, &ships.Entity) {
-				ships.Destroy()
-				delete(shipMap,i)
+			if ships.CanBeHit() && canHurt(bigExplosion.Owner, i) {
+				if damage := bigExplosion.DamageTo(&ships.Entity); damage > 0 {
+					if mode.Physics {
+						ships.Push(bigExplosion.ImpactOn(&ships.Entity))
+					}
+					ships.Damage(damage, bigExplosion.Owner)
+				}
+			}
+		}
+		// chain reaction
+		for _, mine := range mines {
+			if mine.IsAlive() && mine.IsArmed() && IsColliding(&bigExplosion.Entity, &mine.Entity) {
+				mine.Destroy()
+			}
+		}
+	}
+	for i, ships := range shipMap {
+		for _, mine := range mines {
+			if i != mine.Owner && ships.IsAlive() && canHurt(mine.Owner, i) && mine.InRange(&ships.Entity) {
+				mine.Destroy()
+			}
+		}
+	}
 
+	for _, saucer := range saucers {
+		for _, bullet := range bullets {
+			if saucer.IsAlive() && bullet.IsAlive() && bullet.Owner != saucerOwner && IsColliding(&saucer.Entity, &bullet.Entity) {
+				bullet.Destroy()
+				saucer.Destroy(bullet.Owner)
+			}
+		}
+		for _, torpedo := range torpedos {
+			if saucer.IsAlive() && torpedo.IsAlive() && IsColliding(&saucer.Entity, &torpedo.Entity) {
+				torpedo.Destroy()
+				saucer.Destroy(torpedo.Owner)
 			}
 		}
 		for _, mine := range mines {
-			if ship.IsAlive() && IsColliding(&mine.Entity, &ship.Entity) {
+			if saucer.IsAlive() && mine.InRange(&saucer.Entity) {
 				mine.Destroy()
-				ship.Destroy()
+			}
+		}
+		for _, bigExplosion := range bigExplosions {
+			if saucer.IsAlive() && IsColliding(&saucer.Entity, &bigExplosion.Entity) {
+				saucer.Destroy(bigExplosion.Owner)
+			}
+		}
+		for i, ships := range shipMap {
+			if saucer.IsAlive() && ships.CanBeHit() && IsColliding(&saucer.Entity, &ships.Entity) {
+				saucer.Destroy(i)
+				ships.Damage(collisionDamage, saucerOwner)
+			}
+		}
+	}
+	for _, powerUp := range powerUps {
+		// only the local ship collects, other players claim their own
+		if powerUp.IsAlive() && ship.IsAlive() && IsColliding(&powerUp.Entity, &ship.Entity) {
+			powerUp.Collect()
+		}
+	}
+	for _, bullet := range bullets {
+		if bullet.Owner != saucerOwner {
+			continue
+		}
+		for _, ships := range shipMap {
+			if bullet.IsAlive() && ships.CanBeHit() && IsColliding(&bullet.Entity, &ships.Entity) {
+				bullet.Destroy()
+				ships.Damage(bulletDamage, saucerOwner)
+			}
+		}
+	}
+
+	bossHitDetection()
+	gravityHitDetection()
+	arenaHitDetection()
+
+	if mode.PvP {
+		for i, ships := range shipMap {
+			for _, bullet := range bullets {
+				if ships.CanBeHit() && bullet.IsAlive() && canHurt(bullet.Owner, i) && IsColliding(&bullet.Entity, &ships.Entity) {
+					bullet.Destroy()
+					ships.Damage(bulletDamage, bullet.Owner)
+				}
+			}
+			for _, torpedo := range torpedos {
+				if ships.CanBeHit() && torpedo.IsAlive() && canHurt(torpedo.Owner, i) && IsColliding(&torpedo.Entity, &ships.Entity) {
+					torpedo.Destroy()
+					ships.Damage(torpedoDamage, torpedo.Owner)
+				}
 			}
 		}
 	}
+}
+
+// Destroys the ship of player id, hit by a projectile of player owner.
+// The ship's player shares who did it, and the owner counts the frag
+// once it sees the ship go down in updatePlayers.
+func destroyShip(id, owner int) {
+	shipMap[id].LastHitBy = owner
+	shipMap[id].Destroy()
+	delete(shipMap, id)
 }
\ No newline at end of file
@@ -0,0 +1,105 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+* License, v. 2.0. If a copy of the MPL was not distributed with this
+* file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package main
+
+// What an asteroid is made of. Pieces are made of the same stuff as
+// the rock they came from.
+type Material int
+
+const (
+	MaterialRock Material = iota
+	MaterialIce
+	MaterialMetal
+	MaterialExplosive
+	MaterialCrystal
+	numMaterials
+)
+
+type materialType struct {
+	Name       string
+	Colors     []Color // Cycled through the outline's vertices.
+	Chance     float64 // Share of new asteroids made of it.
+	Score      int     // Points for destroying one.
+	Toughness  int     // Multiplies the hit points.
+	Pieces     int     // Number of pieces it splits into.
+	SplitSpeed float64 // Multiplies the speed of the pieces.
+	Explodes   bool    // Goes off in a big explosion when destroyed.
+	DropChance float64 // Chance of leaving a power-up behind.
+}
+
+var materialTypes = []materialType{
+	MaterialRock: materialType{
+		Name:       "rock",
+		Colors:     []Color{Color{1, 1, 1}, Color{0.9, 0.9, 0.9}, Color{0.8, 0.8, 0.85}, Color{0.9, 0.85, 0.85}},
+		Chance:     0.55,
+		Score:      3,
+		Toughness:  1,
+		Pieces:     2,
+		SplitSpeed: 1,
+		DropChance: powerUpDropChance,
+	},
+	MaterialIce: materialType{
+		Name:       "ice",
+		Colors:     []Color{Color{0.7, 0.95, 1}, Color{0.5, 0.8, 1}, Color{0.85, 1, 1}},
+		Chance:     0.15,
+		Score:      2,
+		Toughness:  1,
+		Pieces:     3,
+		SplitSpeed: 1.6,
+		DropChance: powerUpDropChance,
+	},
+	MaterialMetal: materialType{
+		Name:       "metal",
+		Colors:     []Color{Color{0.6, 0.6, 0.7}, Color{0.75, 0.75, 0.8}, Color{0.5, 0.55, 0.6}},
+		Chance:     0.15,
+		Score:      6,
+		Toughness:  3,
+		Pieces:     2,
+		SplitSpeed: 0.7,
+		DropChance: powerUpDropChance,
+	},
+	MaterialExplosive: materialType{
+		Name:       "explosive",
+		Colors:     []Color{Color{1, 0.4, 0.2}, Color{0.9, 0.6, 0.2}, Color{1, 0.3, 0.1}},
+		Chance:     0.1,
+		Score:      4,
+		Toughness:  1,
+		Pieces:     2,
+		SplitSpeed: 1,
+		Explodes:   true,
+		DropChance: powerUpDropChance,
+	},
+	MaterialCrystal: materialType{
+		Name:       "crystal",
+		Colors:     []Color{Color{0.8, 0.4, 1}, Color{0.5, 1, 0.9}, Color{0.9, 0.7, 1}},
+		Chance:     0.05,
+		Score:      8,
+		Toughness:  2,
+		Pieces:     2,
+		SplitSpeed: 1,
+		DropChance: 1,
+	},
+}
+
+// Picks what a new asteroid is made of, by the materials' chances.
+func randomMaterial() Material {
+	x := rng.Float64()
+	for m, material := range materialTypes {
+		if x < material.Chance {
+			return Material(m)
+		}
+		x -= material.Chance
+	}
+	return MaterialRock
+}
+
+// Colors the asteroid's outline in its material's colors.
+func (ast *Asteroid) paint() {
+	colors := materialTypes[ast.Material].Colors
+	ast.Shape.Colors = nil
+	for v := range ast.Shape.Vectors {
+		ast.Shape.Colors = append(ast.Shape.Colors, colors[v%len(colors)])
+	}
+}
@@ -4,11 +4,29 @@
 
 package main
 
+import (
+	"math"
+
+	"github.com/go-gl/gl/v2.1/gl"
+	glfw "github.com/go-gl/glfw3/v3.0/glfw"
+)
+
+const (
+	mineArmDelay      = 1.0
+	mineTriggerRadius = 25.0
+)
+
+// Proximity mine. Once armed, anything of the owner's enemies coming
+// within TriggerRadius sets it off into a big explosion, which in turn
+// sets off other mines it reaches.
 type Mine struct {
 	Entity
+	Owner         int
+	ArmTime       float64
+	TriggerRadius float64
 }
 
-func NewMine(x, y float64) *Mine {
+func NewMine(x, y float64, owner int) *Mine {
 	shape := Polygon{
 		[]Vector{
 			Vector{-2, 2},
@@ -23,7 +41,7 @@ func NewMine(x, y float64) *Mine {
 			Color{0.5, 1, 0},
 		},
 	}
-	mine := &Mine{*NewEntity(shape, x, y, 0, 0.5, 0, 0, 0, 5)}
+	mine := &Mine{*NewEntity(shape, x, y, 0, 0.5, 0, 0, 0, 5), owner, glfw.GetTime() + mineArmDelay, mineTriggerRadius}
 	if rng.Float64() > 0.5 {
 		mine.RotateRight(true)
 	} else {
@@ -32,7 +50,80 @@ func NewMine(x, y float64) *Mine {
 	return mine
 }
 
+func (mine *Mine) Update() {
+	if paused {
+		timediff := (glfw.GetTime() - mine.Entity.lastUpdatedTime)
+		mine.ArmTime = mine.ArmTime + timediff
+	}
+	mine.Entity.Update()
+}
+
+// Draws the mine and, once it is armed, its trigger radius.
+func (mine *Mine) Draw(invertColors bool) {
+	mine.Entity.Draw(invertColors)
+	if !mine.IsAlive() || !mine.IsArmed() {
+		return
+	}
+
+	gl.Begin(gl.POINTS)
+	gl.Color3d(Colorize(0.25), Colorize(0.5), Colorize(0))
+	for i := 0; i < 24; i++ {
+		rad := float64(i) * math.Pi / 12
+		gl.Vertex2d(mine.PosX+mine.TriggerRadius*math.Cos(rad), mine.PosY+mine.TriggerRadius*math.Sin(rad))
+	}
+	gl.End()
+}
+
+func (mine *Mine) IsArmed() bool {
+	return glfw.GetTime() >= mine.ArmTime
+}
+
+// Reports whether ent is close enough to set the armed mine off.
+func (mine *Mine) InRange(ent *Entity) bool {
+	return mine.IsAlive() && mine.IsArmed() &&
+		wrappedDistance(mine.PosX, mine.PosY, ent.PosX, ent.PosY)-ent.Radius() <= mine.TriggerRadius
+}
+
+// Blows the mine up into a big explosion that hurts the owner's
+// enemies.
 func (mine *Mine) Destroy() {
+	if !mine.Entity.IsAlive() {
+		return
+	}
 	mine.Entity.Destroy()
-	explosions = append(explosions, NewExplosion(mine.PosX, mine.PosY, 10))
+	explosion := NewBigExplosion(mine.PosX, mine.PosY, 2, mine.Owner)
+	explosion.MaxLifetime = 0.5
+	bigExplosions = append(bigExplosions, explosion)
+}
+
+// Drops proximity mines behind the ship.
+type MineLayer struct {
+	Magazine
+}
+
+func init() {
+	RegisterWeapon(2, func() Weapon { return &MineLayer{NewMagazine(3, 3)} })
+}
+
+func (layer *MineLayer) Name() string {
+	return "mines"
+}
+
+func (layer *MineLayer) Cooldown(ship *Ship) float64 {
+	return 0.3
+}
+
+func (layer *MineLayer) Spread(ship *Ship) []float64 {
+	return []float64{0}
+}
+
+func (layer *MineLayer) Launch(ship *Ship, angle float64) {
+	x, y := RotateVector(&Vector{0, -10}, angle)
+
+	mine := NewMine(ship.PosX+x, ship.PosY+y, ship.PlayerId)
+	mines = append(mines, mine)
+}
+
+func (layer *MineLayer) Automatic() bool {
+	return false
 }
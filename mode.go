@@ -0,0 +1,301 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+* License, v. 2.0. If a copy of the MPL was not distributed with this
+* file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Rules of a game. The host picks the mode and shares it through
+// GameNode, so every player plays by the same rules.
+type GameMode struct {
+	Name           string
+	PvP            bool    // Projectiles can hit other players' ships.
+	Teams          int     // Number of teams, 0 for every player on their own.
+	FriendlyFire   bool    // Projectiles can hit teammates' ships.
+	SelfDamage     bool    // Projectiles can hit the ship that fired them.
+	Lives          int     // Ships per player, 0 for unlimited.
+	ExtraLifeScore int     // Points needed for an extra life, 0 for none.
+	RespawnDelay   float64 // Seconds before a destroyed ship comes back.
+	SpawnRadius    float64 // Room to keep around new ships and asteroids.
+	HyperspaceRisk float64 // Chance a hyperspace jump destroys the ship or lands it anywhere.
+	FragLimit      int     // Match ends when a player reaches it, 0 for none.
+	TimeLimit      float64 // Match length in seconds, 0 for none.
+	Physics        bool    // Asteroids and ships bounce off each other, hits push them.
+	SplitRule      string  // How asteroid pieces fly off, see splitRules.
+	Fracture       bool    // Asteroids break into real shards along the shot instead of splitting.
+	Arena          bool    // Walls around the field instead of wrap-around.
+	WorldWidth     float64 // Size of the field, 0 to fit each player's window.
+	WorldHeight    float64
+}
+
+var gameModes = map[string]GameMode{
+	"classic": GameMode{
+		Name:           "classic",
+		PvP:            false,
+		SelfDamage:     true,
+		Lives:          3,
+		ExtraLifeScore: 500,
+		RespawnDelay:   2,
+		SpawnRadius:    60,
+		HyperspaceRisk: 0.1,
+		SplitRule:      "random",
+	},
+	"deathmatch": GameMode{
+		Name:           "deathmatch",
+		PvP:            true,
+		SelfDamage:     false,
+		RespawnDelay:   3,
+		SpawnRadius:    60,
+		HyperspaceRisk: 0.1,
+		FragLimit:      10,
+		TimeLimit:      300,
+		Physics:        true,
+		SplitRule:      "physics",
+	},
+	"team": GameMode{
+		Name:           "team",
+		PvP:            true,
+		Teams:          2,
+		FriendlyFire:   false,
+		SelfDamage:     false,
+		RespawnDelay:   3,
+		SpawnRadius:    60,
+		HyperspaceRisk: 0.1,
+		FragLimit:      20,
+		TimeLimit:      300,
+		Physics:        true,
+		SplitRule:      "physics",
+	},
+	"arena": GameMode{
+		Name:           "arena",
+		PvP:            true,
+		SelfDamage:     false,
+		RespawnDelay:   3,
+		SpawnRadius:    60,
+		HyperspaceRisk: 0.1,
+		FragLimit:      10,
+		TimeLimit:      300,
+		Physics:        true,
+		SplitRule:      "physics",
+		Arena:          true,
+	},
+}
+
+// Looks up a game mode by name.
+func GetGameMode(name string) (GameMode, error) {
+	m, ok := gameModes[name]
+	if !ok {
+		return GameMode{}, fmt.Errorf("unknown game mode %q", name)
+	}
+	return m, nil
+}
+
+// Owner of blasts nobody set off, like a rock going off by itself.
+const noOwner = -2
+
+// Reports whether a projectile fired by player owner may
+// destroy the ship of player victim.
+func canHurt(owner, victim int) bool {
+	if owner == saucerOwner {
+		return true
+	}
+	if owner == noOwner {
+		// Nobody to blame, so it counts as the victim's own blast.
+		return mode.SelfDamage
+	}
+	if owner == victim {
+		return mode.SelfDamage
+	}
+	if !mode.PvP {
+		return false
+	}
+	if mode.Teams > 0 && teamOf(owner) == teamOf(victim) {
+		return mode.FriendlyFire
+	}
+	return true
+}
+
+// Records that the local player destroyed the ship of player victim
+// and lets the victim know through paxos.
+func registerFrag(victim int) {
+	kills[victim] += 1
+	gameNode.ShareFrags(matchEpoch, kills)
+}
+
+// Seconds since the Unix epoch. Unlike glfw's timer it reads the same
+// on every player's machine, so it can time a shared match.
+func wallTime() float64 {
+	return float64(time.Now().UnixNano()) / 1e9
+}
+
+// Starts a new match with no frags for anyone. Only the host starts
+// matches, the others follow in updateMatch.
+func startMatch() {
+	matchEpoch += 1
+	matchStartTime = wallTime()
+	gameNode.ShareMatch(matchEpoch, matchStartTime)
+	frags = make(map[int]map[int]int)
+	kills = make(map[int]int)
+	killedBy = make(map[int]int)
+	hits = make(map[int]int)
+	hitsTaken = make(map[int]int)
+}
+
+// Catches up with the host's match. Frags already scored in it carry
+// on, so a ship coming back isn't destroyed for old frags.
+func joinMatch() {
+	if epoch, start, err := gameNode.GetMatch(); err == nil {
+		matchEpoch, matchStartTime = epoch, start
+	}
+	frags = gameNode.GetFrags(matchEpoch)
+	kills = make(map[int]int)
+	for victim, count := range frags[PlayerId] {
+		kills[victim] = count
+	}
+	killedBy = make(map[int]int)
+	for attacker, victims := range frags {
+		killedBy[attacker] = victims[PlayerId]
+	}
+	shared := gameNode.GetHits(matchEpoch)
+	hits = make(map[int]int)
+	for victim, count := range shared[PlayerId] {
+		hits[victim] = count
+	}
+	hitsTaken = make(map[int]int)
+	for attacker, victims := range shared {
+		hitsTaken[attacker] = victims[PlayerId]
+	}
+}
+
+// Follows the host into new matches. The host starts one when a
+// player asks for it after the match is over.
+func updateMatch() {
+	if !isClient {
+		for _, epoch := range gameNode.GetMatchRequests() {
+			if epoch == matchEpoch && isMatchOver() {
+				startMatch()
+				break
+			}
+		}
+		return
+	}
+	if epoch, _, err := gameNode.GetMatch(); err == nil && epoch != matchEpoch {
+		joinMatch()
+	}
+}
+
+// Catches up with the frags other players scored. A frag on the
+// local ship is only registered after the ship was destroyed, so
+// there is nothing left to do to it.
+func updateFrags() {
+	frags = gameNode.GetFrags(matchEpoch)
+	for attacker, victims := range frags {
+		if attacker != PlayerId {
+			killedBy[attacker] = victims[PlayerId]
+		}
+	}
+}
+
+// Total number of frags scored by player id. Destroying your own
+// ship or a teammate's doesn't count.
+func fragCount(id int) int {
+	total := 0
+	for victim, count := range frags[id] {
+		if victim == id || (mode.Teams > 0 && teamOf(victim) == teamOf(id)) {
+			continue
+		}
+		total += count
+	}
+	return total
+}
+
+// Seconds left in the match, or -1 if there is no time limit.
+func matchTimeLeft() float64 {
+	if mode.TimeLimit <= 0 {
+		return -1
+	}
+	left := mode.TimeLimit - (wallTime() - matchStartTime)
+	if left < 0 {
+		left = 0
+	}
+	return left
+}
+
+func isMatchOver() bool {
+	if !mode.PvP {
+		return false
+	}
+	if matchTimeLeft() == 0 {
+		return true
+	}
+	if mode.FragLimit > 0 {
+		for id := range frags {
+			if fragCount(id) >= mode.FragLimit {
+				return true
+			}
+		}
+		for t := 0; t < mode.Teams; t++ {
+			if teamScore(t) >= mode.FragLimit {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Sorts player ids by frags, best first.
+type byFrags []int
+
+func (ids byFrags) Len() int      { return len(ids) }
+func (ids byFrags) Swap(i, j int) { ids[i], ids[j] = ids[j], ids[i] }
+func (ids byFrags) Less(i, j int) bool {
+	if fragCount(ids[i]) != fragCount(ids[j]) {
+		return fragCount(ids[i]) > fragCount(ids[j])
+	}
+	return ids[i] < ids[j]
+}
+
+func rankedPlayers() []int {
+	ids := gameNode.PlayerIds()
+	sort.Sort(byFrags(ids))
+	return ids
+}
+
+func drawScoreboard() {
+	y := fieldSize - 20.0
+	if left := matchTimeLeft(); left >= 0 {
+		DrawString(screenWidth-110, y, 1, Color{1, 1, 1}, fmt.Sprintf("time: %d", int(left)))
+		y -= 12
+	}
+	if mode.Teams > 0 {
+		y = drawTeamScores(y) - 6
+	}
+	for _, id := range rankedPlayers() {
+		color := Color{0.5, 0.5, 0.5}
+		if id == PlayerId {
+			color = Color{1, 1, 1}
+		}
+		if team := teamOf(id); team >= 0 {
+			color = teams[team].Color
+		}
+		DrawString(screenWidth-110, y, 1, color, fmt.Sprintf("%s: %d", gameNode.PlayerName(id), fragCount(id)))
+		y -= 12
+	}
+}
+
+func drawMatchOverScreen() {
+	DrawString(fieldSize/2-20, fieldSize/2+10, 5, Color{1, 1, 1}, fmt.Sprintf("Match Over!"))
+	if mode.Teams > 0 {
+		winner := leadingTeam()
+		DrawString(fieldSize/2-120, fieldSize/2-20, 1.5, teams[winner].Color, fmt.Sprintf("Team %s wins with %d frags", teams[winner].Name, teamScore(winner)))
+	} else if ranked := rankedPlayers(); len(ranked) > 0 {
+		winner := ranked[0]
+		DrawString(fieldSize/2-120, fieldSize/2-20, 1.5, Color{1, 1, 1}, fmt.Sprintf("%s wins with %d frags", gameNode.PlayerName(winner), fragCount(winner)))
+	}
+	DrawString(fieldSize/2-120, fieldSize/2-50, 1.5, Color{1, 1, 1}, fmt.Sprintf("Press R to start a new match"))
+}
@@ -0,0 +1,135 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+* License, v. 2.0. If a copy of the MPL was not distributed with this
+* file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package main
+
+import (
+	"math"
+	"sort"
+)
+
+// Impulse a bullet or laser hit gives, and a big explosion gives at
+// its center.
+const (
+	bulletImpulse    = 10.0
+	explosionImpulse = 80.0
+)
+
+// Mass of the entity, its polygon's area.
+func (ent *Entity) Mass() float64 {
+	return math.Max(1, polygonArea(ent.Shape.Vectors))
+}
+
+// Changes the entity's velocity by an impulse of jx, jy.
+func (ent *Entity) ApplyImpulse(jx, jy float64) {
+	mass := ent.Mass()
+	ent.VelocityX += jx / mass
+	ent.VelocityY += jy / mass
+}
+
+// Where and how hard something hit an entity.
+type Impact struct {
+	X, Y               float64
+	ImpulseX, ImpulseY float64
+}
+
+// Impact of something at x, y on ent, pushing it away from x, y.
+func impactFrom(x, y float64, ent *Entity, strength float64) Impact {
+	dx, dy := wrappedOffset(x, y, ent.PosX, ent.PosY)
+	d := math.Hypot(dx, dy)
+	if d == 0 {
+		return Impact{x, y, 0, 0}
+	}
+	return Impact{x, y, dx / d * strength, dy / d * strength}
+}
+
+// Impact of projectile, pushing in the direction it flies in.
+func impactOf(projectile *Entity, strength float64) Impact {
+	speed := math.Hypot(projectile.VelocityX, projectile.VelocityY)
+	if speed == 0 {
+		return Impact{projectile.PosX, projectile.PosY, 0, 0}
+	}
+	return Impact{projectile.PosX, projectile.PosY,
+		projectile.VelocityX / speed * strength, projectile.VelocityY / speed * strength}
+}
+
+// Impact of ent running into something with all its momentum.
+func ramImpact(ent *Entity) Impact {
+	mass := ent.Mass()
+	return Impact{ent.PosX, ent.PosY, ent.VelocityX * mass, ent.VelocityY * mass}
+}
+
+// Impact of the explosion on ent, harder the closer ent is to its
+// center.
+func (explosion *BigExplosion) ImpactOn(ent *Entity) Impact {
+	reach := explosion.Radius() + ent.Radius()
+	d := wrappedDistance(explosion.PosX, explosion.PosY, ent.PosX, ent.PosY)
+	return impactFrom(explosion.PosX, explosion.PosY, ent, explosionImpulse*math.Max(0, 1-d/reach))
+}
+
+func (ent *Entity) Push(impact Impact) {
+	ent.ApplyImpulse(impact.ImpulseX, impact.ImpulseY)
+}
+
+// Elastic collision between a and b, treated as discs hitting each
+// other along the line between their centers. Nothing happens if they
+// are already moving apart, so overlapping entities don't get stuck.
+func bounce(a, b *Entity) {
+	dx, dy := wrappedOffset(a.PosX, a.PosY, b.PosX, b.PosY)
+	d := math.Hypot(dx, dy)
+	if d == 0 {
+		return
+	}
+	nx, ny := dx/d, dy/d
+
+	approach := (b.VelocityX-a.VelocityX)*nx + (b.VelocityY-a.VelocityY)*ny
+	if approach >= 0 {
+		return
+	}
+	j := -2 * approach / (1/a.Mass() + 1/b.Mass())
+	a.ApplyImpulse(-j*nx, -j*ny)
+	b.ApplyImpulse(j*nx, j*ny)
+}
+
+// Cheap check before the polygon test, whether a and b are close
+// enough to touch at all.
+func mayCollide(a, b *Entity) bool {
+	return wrappedDistance(a.PosX, a.PosY, b.PosX, b.PosY) < a.Radius()+b.Radius()
+}
+
+// Bounces asteroids off each other and ships off each other. Only
+// called in game modes with physics.
+func updatePhysics() {
+	var ids []int
+	for id, asteroid := range asteroids {
+		if asteroid.IsAlive() {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+	for i := range ids {
+		for j := i + 1; j < len(ids); j++ {
+			a, b := &asteroids[ids[i]].Entity, &asteroids[ids[j]].Entity
+			if mayCollide(a, b) && IsColliding(a, b) {
+				bounce(a, b)
+			}
+		}
+	}
+
+	ids = nil
+	for id, ships := range shipMap {
+		if ships.IsAlive() {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+	for i := range ids {
+		for j := i + 1; j < len(ids); j++ {
+			a, b := &shipMap[ids[i]].Entity, &shipMap[ids[j]].Entity
+			if mayCollide(a, b) && IsColliding(a, b) {
+				bounce(a, b)
+			}
+		}
+	}
+}
@@ -0,0 +1,149 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+* License, v. 2.0. If a copy of the MPL was not distributed with this
+* file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package main
+
+import (
+	"math"
+
+	"github.com/go-gl/gl/v2.1/gl"
+)
+
+// One end of a portal pair: a line Length long through X, Y, facing
+// Angle (0 pointing up, like an Entity's Angle).
+type Portal struct {
+	X, Y   float64
+	Angle  float64
+	Length float64
+}
+
+// Two portals leading into each other. Whatever flies into the front
+// of one comes out of the front of the other, turned by the angle
+// between them, and the same for the backs.
+type PortalPair struct {
+	A, B Portal
+}
+
+// Portal pair of a level file. Positions are fractions of the field
+// size, angles in degrees.
+type portalSpec struct {
+	X1, Y1, Angle1 float64
+	X2, Y2, Angle2 float64
+	Length         float64
+}
+
+// Portals of the level file the host loaded, if any.
+func levelPortals() []*PortalPair {
+	var portals []*PortalPair
+	if gameNode.Level != nil {
+		for _, spec := range gameNode.Level.Portals {
+			portals = append(portals, &PortalPair{
+				Portal{spec.X1 * gameWidth, spec.Y1 * gameHeight, spec.Angle1, spec.Length},
+				Portal{spec.X2 * gameWidth, spec.Y2 * gameHeight, spec.Angle2, spec.Length},
+			})
+		}
+	}
+	return portals
+}
+
+// Direction the portal faces and the one along its line.
+func (portal *Portal) axes() (nx, ny, tx, ty float64) {
+	rad := portal.Angle * math.Pi / 180
+	return math.Sin(rad), math.Cos(rad), math.Cos(rad), -math.Sin(rad)
+}
+
+// Position of x, y in front of and along the portal.
+func (portal *Portal) local(x, y float64) (float64, float64) {
+	nx, ny, tx, ty := portal.axes()
+	dx, dy := wrappedOffset(portal.X, portal.Y, x, y)
+	return dx*nx + dy*ny, dx*tx + dy*ty
+}
+
+// Reports whether ent crossed the portal's line since its last update,
+// and where along the line and how far past it it is now.
+func (portal *Portal) Crossed(ent *Entity) (bool, float64, float64) {
+	dx, dy := wrappedOffset(ent.PosX, ent.PosY, ent.prevX, ent.prevY)
+	before, alongBefore := portal.local(ent.PosX+dx, ent.PosY+dy)
+	after, alongAfter := portal.local(ent.PosX, ent.PosY)
+	if (before < 0) == (after < 0) || before == after {
+		return false, 0, 0
+	}
+	along := alongBefore + before/(before-after)*(alongAfter-alongBefore)
+	return math.Abs(along) <= portal.Length/2, along, after
+}
+
+// Turns x, y the way going from portal in into portal out does.
+func turnThrough(in, out *Portal, x, y float64) (float64, float64) {
+	inNX, inNY, inTX, inTY := in.axes()
+	outNX, outNY, outTX, outTY := out.axes()
+	n, t := x*inNX+y*inNY, x*inTX+y*inTY
+	return -n*outNX - t*outTX, -n*outNY - t*outTY
+}
+
+// Moves ent out of portal out after it crossed portal in at along,
+// depth past its line.
+func teleport(in, out *Portal, ent *Entity, along, depth float64) {
+	nx, ny, tx, ty := out.axes()
+	ent.PosX = out.X - along*tx - depth*nx
+	ent.PosY = out.Y - along*ty - depth*ny
+	ent.prevX, ent.prevY = ent.PosX, ent.PosY
+	ent.VelocityX, ent.VelocityY = turnThrough(in, out, ent.VelocityX, ent.VelocityY)
+
+	rad := ent.Angle * math.Pi / 180
+	hx, hy := turnThrough(in, out, math.Sin(rad), math.Cos(rad))
+	ent.Angle = math.Mod(math.Atan2(hx, hy)*180/math.Pi+360, 360)
+}
+
+// Sends ent through whichever end of the pair it flew into.
+func (pair *PortalPair) Pass(ent *Entity) {
+	if crossed, along, depth := pair.A.Crossed(ent); crossed {
+		teleport(&pair.A, &pair.B, ent, along, depth)
+	} else if crossed, along, depth := pair.B.Crossed(ent); crossed {
+		teleport(&pair.B, &pair.A, ent, along, depth)
+	}
+}
+
+// Draws the portal's line in color, with short marks on the side it
+// faces.
+func (portal *Portal) Draw(color Color) {
+	nx, ny, tx, ty := portal.axes()
+	half := portal.Length / 2
+
+	gl.Begin(gl.LINES)
+	gl.Color3d(Colorize(color.R), Colorize(color.G), Colorize(color.B))
+	gl.Vertex2d(portal.X-half*tx, portal.Y-half*ty)
+	gl.Vertex2d(portal.X+half*tx, portal.Y+half*ty)
+	for i := -2.0; i <= 2; i++ {
+		x, y := portal.X+i*half/2*tx, portal.Y+i*half/2*ty
+		gl.Vertex2d(x, y)
+		gl.Vertex2d(x+3*nx, y+3*ny)
+	}
+	gl.End()
+}
+
+func (pair *PortalPair) Draw() {
+	pair.A.Draw(Color{0.3, 0.6, 1})
+	pair.B.Draw(Color{1, 0.6, 0.2})
+}
+
+// Sends everything that flew into a portal out of its other end.
+func passPortals() {
+	if paused {
+		return
+	}
+	for _, pair := range portals {
+		for _, ships := range shipMap {
+			pair.Pass(&ships.Entity)
+		}
+		for _, bullet := range bullets {
+			pair.Pass(&bullet.Entity)
+		}
+		for _, torpedo := range torpedos {
+			pair.Pass(&torpedo.Entity)
+		}
+		for _, asteroid := range asteroids {
+			pair.Pass(&asteroid.Entity)
+		}
+	}
+}
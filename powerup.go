@@ -0,0 +1,200 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+* License, v. 2.0. If a copy of the MPL was not distributed with this
+* file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package main
+
+import (
+	"math/rand"
+
+	glfw "github.com/go-gl/glfw3/v3.0/glfw"
+)
+
+type PowerUpKind int
+
+const (
+	PowerUpAmmo PowerUpKind = iota
+	PowerUpRapidFire
+	PowerUpSpreadShot
+	PowerUpShield
+	PowerUpExtraLife
+	numPowerUpKinds
+)
+
+type powerUpType struct {
+	Letter   string
+	Color    Color
+	Duration float64 // Seconds the effect lasts on the ship, 0 if instant.
+}
+
+var powerUpTypes = []powerUpType{
+	PowerUpAmmo:       powerUpType{"A", Color{1, 0.8, 0}, 0},
+	PowerUpRapidFire:  powerUpType{"R", Color{1, 0.3, 0.3}, 10},
+	PowerUpSpreadShot: powerUpType{"S", Color{1, 0.5, 1}, 10},
+	PowerUpShield:     powerUpType{"E", Color{0.3, 0.8, 1}, 0},
+	PowerUpExtraLife:  powerUpType{"L", Color{0.3, 1, 0.3}, 0},
+}
+
+// Chance that a destroyed asteroid leaves a power-up behind.
+const powerUpDropChance = 0.1
+
+// Claim recorded in paxos for a power-up nobody picked up in time.
+const powerUpExpired = -1
+
+// Pickup floating where an asteroid was destroyed. Whoever destroys
+// the asteroid shares the power-up; ships touching it ask the host
+// for it, and the host decides who gets it.
+type PowerUp struct {
+	Entity
+	Id          int
+	Kind        PowerUpKind
+	MaxLifetime float64
+}
+
+func NewPowerUp(id int, kind PowerUpKind, x, y float64) *PowerUp {
+	color := powerUpTypes[kind].Color
+	shape := Polygon{
+		[]Vector{
+			Vector{0, 6},
+			Vector{6, 0},
+			Vector{0, -6},
+			Vector{-6, 0},
+		},
+		[]Color{color, color, color, color},
+	}
+	return &PowerUp{*NewEntity(shape, x, y, 0, 0, 0, 0, 0, 5), id, kind, 10}
+}
+
+// Leaves a power-up behind the asteroid with the given id, with a
+// chance of chance. The id decides, so every node destroying the
+// same asteroid drops the same power-up.
+func dropPowerUp(id int, x, y, chance float64) {
+	r := rand.New(rand.NewSource(int64(id)))
+	if r.Float64() >= chance {
+		return
+	}
+	if _, ok := powerUps[id]; ok || settledPowerUps[id] {
+		return
+	}
+	powerUp := NewPowerUp(id, PowerUpKind(r.Intn(int(numPowerUpKinds))), x, y)
+	powerUps[id] = powerUp
+
+	spawnedPowerUps = append(spawnedPowerUps, id)
+	gameNode.SharePowerUp(powerUp, spawnedPowerUps)
+}
+
+func (powerUp *PowerUp) Update() {
+	if paused {
+		timediff := (glfw.GetTime() - powerUp.Entity.lastUpdatedTime)
+		powerUp.MaxLifetime = powerUp.MaxLifetime + timediff
+	}
+	powerUp.Entity.Update()
+}
+
+func (powerUp *PowerUp) Draw() {
+	if powerUp.IsAlive() {
+		powerUp.Entity.Draw(false)
+		DrawString(powerUp.PosX-1.2, powerUp.PosY-2.4, 0.6, powerUpTypes[powerUp.Kind].Color, powerUpTypes[powerUp.Kind].Letter)
+	}
+}
+
+func (powerUp *PowerUp) IsExpired() bool {
+	return glfw.GetTime() > powerUp.createdTime+powerUp.MaxLifetime
+}
+
+// Asks the host for the power-up for the local ship. Only one player
+// gets it, the others just see it disappear.
+func (powerUp *PowerUp) Collect() {
+	powerUp.Destroy()
+	settledPowerUps[powerUp.Id] = true
+	pendingPowerUps[powerUp.Id] = powerUp.Kind
+	requestedPowerUps = append(requestedPowerUps, powerUp.Id)
+	gameNode.SharePowerUpRequests(requestedPowerUps)
+}
+
+// Gives the ship the effect of a power-up.
+func (ship *Ship) ApplyPowerUp(kind PowerUpKind) {
+	switch kind {
+	case PowerUpAmmo:
+		for _, weapon := range ship.Weapons {
+			weapon.Refill()
+		}
+	case PowerUpShield:
+		ship.Shield.AddEnergy(ship.Shield.MaxEnergy)
+		ship.Hull = maxHull
+	case PowerUpExtraLife:
+		if mode.Lives > 0 {
+			lives += 1
+		} else {
+			ship.Shield.AddEnergy(ship.Shield.MaxEnergy)
+		}
+	}
+	if duration := powerUpTypes[kind].Duration; duration > 0 {
+		ship.effects[kind] = glfw.GetTime() + duration
+	}
+}
+
+// Reports whether a timed power-up is active on the ship.
+func (ship *Ship) HasEffect(kind PowerUpKind) bool {
+	return glfw.GetTime() < ship.effects[kind]
+}
+
+// Picks up power-ups other players dropped, drops the ones that
+// were collected or have expired and applies the ones the host gave
+// the local player.
+func updatePowerUps() {
+	if !isClient {
+		settlePowerUps()
+	}
+
+	for _, id := range gameNode.GetPowerUpIds() {
+		if _, ok := powerUps[id]; ok || settledPowerUps[id] {
+			continue
+		}
+		if powerUp, err := gameNode.GetPowerUp(id); err == nil {
+			powerUps[id] = powerUp
+		}
+	}
+
+	for id, powerUp := range powerUps {
+		if _, ok := gameNode.PowerUpClaim(id); ok {
+			powerUp.Destroy()
+			settledPowerUps[id] = true
+		}
+	}
+
+	for id, kind := range pendingPowerUps {
+		if claimant, ok := gameNode.PowerUpClaim(id); ok {
+			if claimant == PlayerId {
+				if !ship.IsAlive() {
+					// keep it for the next ship
+					continue
+				}
+				ship.ApplyPowerUp(kind)
+			}
+			delete(pendingPowerUps, id)
+		}
+	}
+}
+
+// Hands out the power-ups players asked for, in player order when
+// several want the same one, and expires the ones nobody picked up in
+// time. Only called on the host, so every claim has a single writer.
+func settlePowerUps() {
+	requests := gameNode.GetPowerUpRequests()
+	for _, id := range gameNode.PlayerIds() {
+		for _, powerUpId := range requests[id] {
+			if claimedPowerUps[powerUpId] {
+				continue
+			}
+			if gameNode.ClaimPowerUp(powerUpId, id) == nil {
+				claimedPowerUps[powerUpId] = true
+			}
+		}
+	}
+	for id, powerUp := range powerUps {
+		if powerUp.IsExpired() {
+			gameNode.ClaimPowerUp(id, powerUpExpired)
+		}
+	}
+}
@@ -0,0 +1,293 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+* License, v. 2.0. If a copy of the MPL was not distributed with this
+* file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package main
+
+import (
+	"math"
+
+	glfw "github.com/go-gl/glfw3/v3.0/glfw"
+)
+
+// Owner of projectiles fired by saucers. Saucers shoot at everyone.
+const saucerOwner = -1
+
+// Hostile flying saucer. Large saucers shoot at the nearest ship more
+// or less at random, small ones are faster and lead their aim. The
+// host runs the saucers; everyone else mirrors them through paxos.
+type Saucer struct {
+	Entity
+	Id          int
+	Small       bool
+	Shots       int     // Shots fired so far.
+	AimAngle    float64 // Direction of the last shot.
+	MaxLifetime float64
+	nextShot    float64
+	nextTurn    float64
+}
+
+func NewSaucer(x, y, vX, vY float64, small bool) *Saucer {
+	size := 1.0
+	if small {
+		size = 0.6
+	}
+	shape := Polygon{
+		[]Vector{
+			Vector{-10 * size, 0},
+			Vector{-4 * size, 3 * size},
+			Vector{-2 * size, 6 * size},
+			Vector{2 * size, 6 * size},
+			Vector{4 * size, 3 * size},
+			Vector{10 * size, 0},
+			Vector{4 * size, -3 * size},
+			Vector{-4 * size, -3 * size},
+		},
+		[]Color{
+			Color{0.6, 1, 0.6},
+			Color{0.6, 1, 0.6},
+			Color{0.8, 1, 0.8},
+			Color{0.8, 1, 0.8},
+			Color{0.6, 1, 0.6},
+			Color{0.6, 1, 0.6},
+			Color{0.4, 0.8, 0.4},
+			Color{0.4, 0.8, 0.4},
+		},
+	}
+	saucer := &Saucer{*NewEntity(shape, x, y, 0, 0, vX, vY, 0, 5), NextSaucerId(), small, 0, 0, 15, 0, 0}
+	saucer.nextShot = glfw.GetTime() + saucer.shotInterval()
+	saucer.nextTurn = glfw.GetTime() + 1
+	return saucer
+}
+
+// Gets a unique ID (paxos-wide) to assign to a new Saucer.
+func NextSaucerId() int {
+	id := (SaucerCounter << 5) | PlayerId
+	SaucerCounter += 1
+	return id
+}
+
+// Seconds between saucer spawns, getting shorter as difficulty rises.
+func saucerSpawnInterval() float64 {
+	return math.Max(5, 25-float64(difficulty))
+}
+
+// Launches a saucer from the left or right edge of the field.
+// Small saucers get more likely as difficulty rises.
+func CreateSaucer() {
+	small := rng.Float64() < math.Min(0.8, float64(difficulty)/20)
+	speed := 0.08
+	if small {
+		speed = 0.12
+	}
+	x := 0.0
+	if rng.Float64() > 0.5 {
+		x = gameWidth
+		speed = -speed
+	}
+	saucer := NewSaucer(x, rng.Float64()*gameHeight, speed, 0, small)
+	saucers[saucer.Id] = saucer
+}
+
+func (saucer *Saucer) shotInterval() float64 {
+	if saucer.Small {
+		return 1
+	}
+	return 1.5
+}
+
+// Points for shooting the saucer down.
+func (saucer *Saucer) Points() int {
+	if saucer.Small {
+		return 100
+	}
+	return 20
+}
+
+// Flies an erratic course and shoots at the nearest ship. Only
+// called on the host.
+func (saucer *Saucer) Think() {
+	if paused || !saucer.IsAlive() {
+		return
+	}
+	now := glfw.GetTime()
+
+	if now >= saucer.nextTurn {
+		speed := math.Abs(saucer.VelocityX)
+		saucer.VelocityY = speed * float64(rng.Intn(3)-1)
+		saucer.nextTurn = now + 0.5 + rng.Float64()*1.5
+	}
+
+	if now >= saucer.nextShot {
+		saucer.nextShot = now + saucer.shotInterval()
+		target := saucer.nearestShip()
+		if target == nil {
+			return
+		}
+		if saucer.Small {
+			saucer.AimAngle = saucer.leadAngle(target)
+		} else {
+			saucer.AimAngle = headingTo(saucer.PosX, saucer.PosY, target.PosX, target.PosY) + (rng.Float64()-0.5)*40
+		}
+		saucer.fire()
+	}
+}
+
+// Aims where the target will be when the shot gets there.
+func (saucer *Saucer) leadAngle(target *Ship) float64 {
+	dx, dy := wrappedOffset(saucer.PosX, saucer.PosY, target.PosX, target.PosY)
+	vx, vy := target.VelocityX, target.VelocityY
+	speed := saucerBulletSpeed
+
+	// solve |d + v*t| = speed*t for the earliest t > 0
+	a := vx*vx + vy*vy - speed*speed
+	b := 2 * (dx*vx + dy*vy)
+	c := dx*dx + dy*dy
+	t := -1.0
+	if math.Abs(a) < 1e-9 {
+		if b != 0 {
+			t = -c / b
+		}
+	} else if disc := b*b - 4*a*c; disc >= 0 {
+		t1 := (-b - math.Sqrt(disc)) / (2 * a)
+		t2 := (-b + math.Sqrt(disc)) / (2 * a)
+		if t1 > 0 && (t1 < t2 || t2 <= 0) {
+			t = t1
+		} else {
+			t = t2
+		}
+	}
+	if t <= 0 {
+		return math.Atan2(dx, dy) * 180 / math.Pi
+	}
+	return math.Atan2(dx+vx*t, dy+vy*t) * 180 / math.Pi
+}
+
+func (saucer *Saucer) nearestShip() *Ship {
+	var nearest *Ship
+	best := math.Inf(1)
+	for _, ships := range shipMap {
+		if !ships.IsAlive() {
+			continue
+		}
+		d := wrappedDistance(saucer.PosX, saucer.PosY, ships.PosX, ships.PosY)
+		if d < best {
+			nearest, best = ships, d
+		}
+	}
+	return nearest
+}
+
+// Speed of saucer bullets, a bit slower than the ship's.
+const saucerBulletSpeed = 0.35
+
+// Fires a bullet along AimAngle.
+func (saucer *Saucer) fire() {
+	rad := saucer.AimAngle * math.Pi / 180
+	bullet := NewBullet(
+		saucer.PosX+math.Sin(rad)*8,
+		saucer.PosY+math.Cos(rad)*8,
+		saucerBulletSpeed*math.Sin(rad),
+		saucerBulletSpeed*math.Cos(rad),
+		saucerOwner,
+	)
+	bullets = append(bullets, bullet)
+	saucer.Shots += 1
+}
+
+func (saucer *Saucer) Update() {
+	if paused {
+		timediff := (glfw.GetTime() - saucer.Entity.lastUpdatedTime)
+		saucer.MaxLifetime = saucer.MaxLifetime + timediff
+		saucer.nextShot += timediff
+		saucer.nextTurn += timediff
+	}
+	saucer.Entity.Update()
+}
+
+// Saucers leave the field after a while.
+func (saucer *Saucer) IsAlive() bool {
+	if glfw.GetTime() > saucer.createdTime+saucer.MaxLifetime {
+		return false
+	}
+	return saucer.Entity.IsAlive()
+}
+
+// Shoots the saucer down. The player who did it gets the points;
+// clients also tell the host, which owns the saucer.
+func (saucer *Saucer) Destroy(killer int) {
+	if !saucer.Entity.IsAlive() {
+		return
+	}
+	saucer.Entity.Destroy()
+	explosions = append(explosions, NewExplosion(saucer.PosX, saucer.PosY, 4))
+	if killer == PlayerId {
+		addScore(saucer.Points())
+		if isClient {
+			saucerKills[saucer.Id] = true
+			gameNode.ShareSaucerKills(saucerKills)
+		}
+	}
+}
+
+// Launches saucers on a timer. Only called on the host.
+func updateSaucerSpawns() {
+	now := glfw.GetTime()
+	if paused {
+		nextSaucerTime += now - lastSaucerCheck
+	} else if nextSaucerTime == 0 {
+		nextSaucerTime = now + saucerSpawnInterval()
+	} else if now >= nextSaucerTime && len(shipMap) > 0 && !isGameWon() {
+		CreateSaucer()
+		nextSaucerTime = now + saucerSpawnInterval()
+	}
+	lastSaucerCheck = now
+}
+
+// Keeps saucers in sync with paxos. The host runs them and destroys
+// the ones clients shot down, clients copy the host's saucers and
+// replay the shots they fired.
+func updateSaucers() {
+	if !isClient {
+		for _, id := range gameNode.GetSaucerKills() {
+			if saucer, ok := saucers[id]; ok {
+				saucer.Destroy(saucerOwner)
+			}
+		}
+		for _, saucer := range saucers {
+			saucer.Think()
+		}
+		gameNode.ShareSaucers(saucers)
+		return
+	}
+
+	shared := gameNode.GetSaucers()
+	for id, v := range shared {
+		if saucerKills[id] {
+			// Shot down here, the host just doesn't know yet.
+			continue
+		}
+		saucer, ok := saucers[id]
+		if !ok {
+			saucer = NewSaucer(v.PosX, v.PosY, v.VelocityX, v.VelocityY, v.Small)
+			saucer.Id = id
+			saucer.Shots = v.Shots
+			// the host decides when it leaves
+			saucer.MaxLifetime = math.Inf(1)
+			saucers[id] = saucer
+		}
+		saucer.PosX = v.PosX
+		saucer.PosY = v.PosY
+		saucer.VelocityX = v.VelocityX
+		saucer.VelocityY = v.VelocityY
+		saucer.AimAngle = v.AimAngle
+		for saucer.IsAlive() && saucer.Shots < v.Shots {
+			saucer.fire()
+		}
+	}
+	for id := range saucers {
+		if _, ok := shared[id]; !ok {
+			delete(saucers, id)
+		}
+	}
+}
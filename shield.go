@@ -0,0 +1,129 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+* License, v. 2.0. If a copy of the MPL was not distributed with this
+* file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package main
+
+import (
+	"math"
+
+	"github.com/go-gl/gl/v2.1/gl"
+	glfw "github.com/go-gl/glfw3/v3.0/glfw"
+)
+
+// Time a freshly spawned ship is shielded for free, in seconds.
+const spawnInvulnerability = 3
+
+// Energy shield of a ship. While raised it drains energy and keeps
+// the ship from being destroyed, while lowered it recharges.
+type Shield struct {
+	Energy          float64
+	MaxEnergy       float64
+	DrainRate       float64 // Energy per second while raised.
+	RechargeRate    float64 // Energy per second while lowered.
+	raised          bool
+	freeTill        float64 // Shield is up without draining until then.
+	lastUpdatedTime float64
+}
+
+func NewShield() *Shield {
+	return &Shield{
+		Energy:          100,
+		MaxEnergy:       100,
+		DrainRate:       40,
+		RechargeRate:    10,
+		raised:          false,
+		freeTill:        glfw.GetTime() + spawnInvulnerability,
+		lastUpdatedTime: glfw.GetTime(),
+	}
+}
+
+func (shield *Shield) Raise(flag bool) {
+	shield.raised = flag
+}
+
+// Keeps the shield up without draining energy for the given
+// number of seconds.
+func (shield *Shield) RaiseFor(seconds float64) {
+	shield.freeTill = glfw.GetTime() + seconds
+}
+
+func (shield *Shield) IsUp() bool {
+	return shield.FreeTime() > 0 || (shield.raised && shield.Energy > 0)
+}
+
+// Seconds left of shield that doesn't drain energy.
+func (shield *Shield) FreeTime() float64 {
+	return math.Max(0, shield.freeTill-glfw.GetTime())
+}
+
+func (shield *Shield) AddEnergy(energy float64) {
+	shield.Energy = math.Min(shield.MaxEnergy, math.Max(0, shield.Energy+energy))
+}
+
+func (shield *Shield) Update() {
+	timediff := glfw.GetTime() - shield.lastUpdatedTime
+	shield.lastUpdatedTime = glfw.GetTime()
+	if paused {
+		shield.freeTill += timediff
+		return
+	}
+
+	if shield.FreeTime() > 0 {
+		return
+	}
+	if shield.raised {
+		shield.AddEnergy(-shield.DrainRate * timediff)
+	} else {
+		shield.AddEnergy(shield.RechargeRate * timediff)
+	}
+}
+
+// Draws the shield as a circle around ent, fading as it runs low.
+func (shield *Shield) Draw(ent *Entity) {
+	if !shield.IsUp() {
+		return
+	}
+	strength := 1.0
+	if shield.FreeTime() == 0 {
+		strength = 0.4 + 0.6*shield.Energy/shield.MaxEnergy
+	}
+
+	gl.Begin(gl.LINE_LOOP)
+	gl.Color3d(Colorize(0.3*strength), Colorize(0.8*strength), Colorize(1.0*strength))
+	radius := ent.Radius() + 3
+	for i := 0; i < 16; i++ {
+		rad := float64(i) * math.Pi / 8
+		gl.Vertex2d(ent.PosX+radius*math.Cos(rad), ent.PosY+radius*math.Sin(rad))
+	}
+	gl.End()
+}
+
+// Draws the shield energy of the local ship as a bar below the lives.
+func drawShieldBar() {
+	if !ship.IsAlive() {
+		return
+	}
+	drawBar(10, fieldSize-60, 60, ship.Shield.Energy/ship.Shield.MaxEnergy, Color{0.3, 0.8, 1})
+}
+
+// Draws a HUD meter of the given width, filled up to fraction.
+func drawBar(x, y, width, fraction float64, color Color) {
+	level := width * fraction
+
+	gl.Begin(gl.LINE_LOOP)
+	gl.Color3d(Colorize(0.5), Colorize(0.5), Colorize(0.5))
+	gl.Vertex2d(x, y)
+	gl.Vertex2d(x+width, y)
+	gl.Vertex2d(x+width, y+4)
+	gl.Vertex2d(x, y+4)
+	gl.End()
+
+	gl.Begin(gl.LINES)
+	gl.Color3d(Colorize(color.R), Colorize(color.G), Colorize(color.B))
+	for i := 1.0; i < 4; i++ {
+		gl.Vertex2d(x, y+i)
+		gl.Vertex2d(x+level, y+i)
+	}
+	gl.End()
+}
@@ -4,40 +4,33 @@
 
 package main
 
-import (
-	"math"
-	"math/rand"
-	"time"
-
-	glfw "github.com/go-gl/glfw3/v3.0/glfw"
-)
+import glfw "github.com/go-gl/glfw3/v3.0/glfw"
 
 type Ship struct {
 	Entity
-	Friction         float64
-	shooting         bool
-	lastBulletFired  float64
-	bulletsPerSecond float64
-	mines            int
-	torpedos         int
+	Friction     float64
+	shooting     bool
+	Weapons      []Weapon
+	selected     int
+	lastFired    map[string]float64 // When each weapon last fired, by name.
+	PlayerId     int
+	Shield       *Shield
+	Hyperspace   *Hyperspace
+	effects      map[PowerUpKind]float64 // Power-up effects and when they wear off.
+	LockedTarget Target
+	Hull         int
+	LastHitBy    int     // Player whose hit destroyed the ship, noOwner if none.
+	hitPause     float64 // Until when hits on another player's ship aren't reported again.
 }
 
-func NewShip(x, y, angle, friction float64) *Ship {
-	var tip Color
-	if PlayerId == 0 {
-		tip = Color{1.0, 0.0, 0.0}
-	} else if PlayerId == 1 {
-		tip = Color{0.0, 1.0, 0.0}
-	} else if PlayerId == 2 {
-		tip = Color{0.0, 0.0, 1.0}
-	} else {
-		r := rand.New(rand.NewSource(time.Now().UnixNano()))
-		f1 := r.Float64()
-		f2 := r.Float64()
-		f3 := r.Float64()
-		tip = Color{f1, f2, f3}
-	}
-	shape := Polygon{
+func NewShip(id int, x, y, angle, friction float64) *Ship {
+	return &Ship{*NewEntity(shipShape(id), x, y, angle, 0.5, 0, 0, 0.0025, 0.25), friction, false, NewInventory(), 0, make(map[string]float64), id, NewShield(), NewHyperspace(), make(map[PowerUpKind]float64), nil, maxHull, noOwner, 0}
+}
+
+// Outline of player id's ship, its tip in the player's color.
+func shipShape(id int) Polygon {
+	tip := shipColor(id)
+	return Polygon{
 		[]Vector{
 			Vector{0, 5},
 			Vector{4, -5},
@@ -49,61 +42,63 @@ func NewShip(x, y, angle, friction float64) *Ship {
 			Color{1.0, 1.0, 1.0},
 		},
 	}
-	return &Ship{*NewEntity(shape, x, y, angle, 0.5, 0, 0, 0.0025, 0.25), friction, false, 0, 5, 3, 1}
 }
 
-func (ship *Ship) DropMine() {
-	if ship.IsAlive() && ship.mines > 0 {
-		x, y := RotateVector(&Vector{0, -10}, ship.Angle)
+// Reports whether anything may destroy the ship right now.
+func (ship *Ship) CanBeHit() bool {
+	return ship.IsAlive() && !ship.Shield.IsUp() && glfw.GetTime() >= ship.hitPause
+}
 
-		mine := NewMine(ship.PosX+x, ship.PosY+y)
-		mines = append(mines, mine)
+func (ship *Ship) RaiseShield(flag bool) {
+	ship.Shield.Raise(flag)
+}
 
-		ship.mines -= 1
+// Starts charging a hyperspace jump.
+func (ship *Ship) Hyperjump() {
+	if ship.IsAlive() {
+		ship.Hyperspace.Engage()
 	}
 }
 
-func (ship *Ship) Shoot(flag bool) {
-	ship.shooting = flag
-	ship.shoot()
+func (ship *Ship) Draw(invertColors bool) {
+	// flicker while charging a hyperspace jump
+	if ship.Hyperspace.IsCharging() && int(glfw.GetTime()*16)%2 == 0 {
+		return
+	}
+	ship.Entity.Draw(invertColors)
+	if ship.IsAlive() {
+		ship.Shield.Draw(&ship.Entity)
+	}
 }
 
-func (ship *Ship) shoot() {
-	if ship.shooting && glfw.GetTime() > ship.lastBulletFired+(1/ship.bulletsPerSecond) && ship.IsAlive() {
-		var rad float64 = ((ship.Angle) * math.Pi) / 180
-		x, y := RotateVector(&Vector{0, 5}, ship.Angle)
-
-		bullet := NewBullet(
-			ship.PosX+x,
-			ship.PosY+y,
-			ship.MaxVelocity*math.Sin(rad)*2,
-			ship.MaxVelocity*math.Cos(rad)*2,
-		)
-		bullets = append(bullets, bullet)
-		ship.lastBulletFired = glfw.GetTime()
+// Pulls or releases the trigger of the selected weapon.
+func (ship *Ship) Shoot(flag bool) {
+	if flag && !ship.shooting {
+		ship.Fire(ship.SelectedWeapon())
 	}
+	ship.shooting = flag
 }
 
-func (ship *Ship) ShootTorpedo() {
-	if ship.IsAlive() && ship.torpedos > 0 {
-		var rad float64 = ((ship.Angle) * math.Pi) / 180
-		x, y := RotateVector(&Vector{0, 8}, ship.Angle)
-
-		torpedo := NewTorpedo(
-			ship.PosX+x,
-			ship.PosY+y,
-			ship.Angle,
-			ship.MaxVelocity*math.Sin(rad)*1.5,
-			ship.MaxVelocity*math.Cos(rad)*1.5,
-		)
-		torpedos = append(torpedos, torpedo)
-
-		ship.torpedos -= 1
+// Keeps automatic weapons firing while the trigger is held.
+func (ship *Ship) shoot() {
+	if ship.shooting && ship.SelectedWeapon().Automatic() {
+		ship.Fire(ship.SelectedWeapon())
 	}
 }
 
 func (ship *Ship) Update() {
+	if paused {
+		// power-ups don't wear off while paused
+		timediff := (glfw.GetTime() - ship.Entity.lastUpdatedTime)
+		for kind := range ship.effects {
+			ship.effects[kind] += timediff
+		}
+	}
 	ship.shoot()
+	ship.Shield.Update()
+	if ship.Hyperspace.Update() && ship.IsAlive() {
+		ship.jump()
+	}
 	ship.Entity.Update()
 	if !paused {
 		ship.AddFrictionToVelocity(ship.Friction)
@@ -112,6 +107,7 @@ func (ship *Ship) Update() {
 
 func (ship *Ship) Destroy() {
 	ship.shooting = false
+	ship.Shield.Raise(false)
 	ship.Entity.Destroy()
 	explosions = append(explosions, NewExplosion(ship.PosX, ship.PosY, 5))
 }
@@ -0,0 +1,78 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+* License, v. 2.0. If a copy of the MPL was not distributed with this
+* file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package main
+
+import "math"
+
+// Number of random points FindSpawnPoint tries before settling
+// for the best one it has seen.
+const spawnAttempts = 50
+
+// Free space around x, y: the distance to the edge of the nearest
+// asteroid, mine, ship or obstacle, to the reach of the nearest
+// gravity well, or to the arena's walls.
+func spawnClearance(x, y float64) float64 {
+	clearance := math.Inf(1)
+	check := func(ent *Entity) {
+		d := wrappedDistance(x, y, ent.PosX, ent.PosY) - ent.Radius()
+		if d < clearance {
+			clearance = d
+		}
+	}
+	for _, asteroid := range asteroids {
+		if asteroid.IsAlive() {
+			check(&asteroid.Entity)
+		}
+	}
+	for _, mine := range mines {
+		if mine.IsAlive() {
+			check(&mine.Entity)
+		}
+	}
+	for _, ships := range shipMap {
+		if ships.IsAlive() {
+			check(&ships.Entity)
+		}
+	}
+	for _, well := range wells {
+		if d := wrappedDistance(x, y, well.X, well.Y) - well.CoreRadius*wellReach; d < clearance {
+			clearance = d
+		}
+	}
+	for _, obstacle := range obstacles {
+		check(&obstacle.Entity)
+	}
+	if mode.Arena {
+		clearance = math.Min(clearance, math.Min(math.Min(x, gameWidth-x), math.Min(y, gameHeight-y)))
+	}
+	return clearance
+}
+
+// Looks for a point at least radius away from every asteroid, mine,
+// ship and gravity well. The preferred points are tried first, then random ones.
+// Returns the clearest point found and whether it is far enough away.
+func FindSpawnPoint(radius float64, preferred ...Vector) (float64, float64, bool) {
+	bestX, bestY, best := 0.0, 0.0, math.Inf(-1)
+	try := func(x, y float64) bool {
+		clearance := spawnClearance(x, y)
+		if clearance > best {
+			bestX, bestY, best = x, y, clearance
+		}
+		return clearance >= radius
+	}
+
+	for _, p := range preferred {
+		if try(p.X, p.Y) {
+			return p.X, p.Y, true
+		}
+	}
+	for i := 0; i < spawnAttempts; i++ {
+		x, y := rng.Float64()*gameWidth, rng.Float64()*gameHeight
+		if try(x, y) {
+			return x, y, true
+		}
+	}
+	return bestX, bestY, false
+}
@@ -0,0 +1,101 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+* License, v. 2.0. If a copy of the MPL was not distributed with this
+* file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// Decides how the pieces of a destroyed asteroid fly off.
+type SplitRule interface {
+	// Velocities of the n children of ast, last hit by ast's impact.
+	// spread scales how fast they fly apart.
+	Split(ast *Asteroid, n int, spread float64) []Vector
+}
+
+// Split rules by name, as game modes refer to them.
+var splitRules = map[string]SplitRule{
+	"random":  RandomSplit{},
+	"physics": ImpactSplit{0.06},
+}
+
+// Looks up a split rule by name.
+func GetSplitRule(name string) (SplitRule, error) {
+	rule, ok := splitRules[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown split rule %q", name)
+	}
+	return rule, nil
+}
+
+// Split rule of the current game mode, random if it names none.
+func splitRule() SplitRule {
+	if rule, err := GetSplitRule(mode.SplitRule); err == nil {
+		return rule
+	}
+	return RandomSplit{}
+}
+
+// Children drift off in random directions, whatever hit the asteroid.
+type RandomSplit struct{}
+
+func (RandomSplit) Split(ast *Asteroid, n int, spread float64) []Vector {
+	var velocities []Vector
+	for i := 0; i < n; i++ {
+		vx, vy := (rng.Float64()-0.5)/4, (rng.Float64()-0.5)/4
+		velocities = append(velocities, Vector{
+			ast.VelocityX + (vx-ast.VelocityX)*spread,
+			ast.VelocityY + (vy-ast.VelocityY)*spread,
+		})
+	}
+	return velocities
+}
+
+// Children share the momentum of the parent and of whatever hit it,
+// weighted by their masses, and fly apart along the hit normal, from
+// the impact point through the center, at least at Speed and faster
+// for harder hits. Their momenta add up to the parent's plus the hit's.
+type ImpactSplit struct {
+	Speed float64
+}
+
+func (rule ImpactSplit) Split(ast *Asteroid, n int, spread float64) []Vector {
+	impact := ast.impact
+
+	// momentum to share; with physics on, the hit has already pushed
+	// the parent
+	mass := ast.Mass()
+	px, py := ast.VelocityX*mass, ast.VelocityY*mass
+	if !mode.Physics {
+		px += impact.ImpulseX
+		py += impact.ImpulseY
+	}
+	// a child is childShrink times smaller across, its area that
+	// squared
+	childMass := mass / (childShrink * childShrink)
+	vx, vy := px/(childMass*float64(n)), py/(childMass*float64(n))
+
+	// hit normal: from the impact point through the center, or else
+	// the way the hit pushed
+	dx, dy := wrappedOffset(impact.X, impact.Y, ast.PosX, ast.PosY)
+	if dx == 0 && dy == 0 {
+		dx, dy = impact.ImpulseX, impact.ImpulseY
+	}
+	heading := headingTo(0, 0, dx, dy)
+	if dx == 0 && dy == 0 {
+		heading = rng.Float64() * 360
+	}
+	speed := (rule.Speed + math.Hypot(impact.ImpulseX, impact.ImpulseY)/mass) * spread
+
+	// spread evenly around the circle, starting on the normal, so the
+	// pieces' separating momenta cancel out
+	var velocities []Vector
+	for i := 0; i < n; i++ {
+		var rad float64 = ((heading + float64(i)*360/float64(n)) * math.Pi) / 180
+		velocities = append(velocities, Vector{vx + speed*math.Sin(rad), vy + speed*math.Cos(rad)})
+	}
+	return velocities
+}
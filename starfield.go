@@ -0,0 +1,85 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+* License, v. 2.0. If a copy of the MPL was not distributed with this
+* file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package main
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/go-gl/gl/v2.1/gl"
+	glfw "github.com/go-gl/glfw3/v3.0/glfw"
+)
+
+// Seed of the starfield, the same for everyone so all players
+// see the same sky.
+const starfieldSeed = 440
+
+// Background of stars in a few layers. Far layers are dim and barely
+// move, near ones are brighter and drift more against the ship's
+// velocity, giving some sense of depth.
+type Starfield struct {
+	Layers          []*StarLayer
+	lastUpdatedTime float64
+}
+
+type StarLayer struct {
+	Stars      []Vector // Fractions of the field size, so they survive a resize.
+	Brightness float64
+	Parallax   float64
+	PointSize  float32
+	offsetX    float64
+	offsetY    float64
+}
+
+func NewStarfield(seed int64) *Starfield {
+	r := rand.New(rand.NewSource(seed))
+	newLayer := func(count int, brightness, parallax float64, pointSize float32) *StarLayer {
+		layer := &StarLayer{nil, brightness, parallax, pointSize, 0, 0}
+		for i := 0; i < count; i++ {
+			layer.Stars = append(layer.Stars, Vector{r.Float64(), r.Float64()})
+		}
+		return layer
+	}
+
+	return &Starfield{
+		[]*StarLayer{
+			newLayer(90, 0.3, 0.05, 1),
+			newLayer(45, 0.55, 0.15, 1.5),
+			newLayer(20, 0.9, 0.3, 2),
+		},
+		glfw.GetTime(),
+	}
+}
+
+// Drifts the layers against the local ship's velocity.
+func (starfield *Starfield) Update() {
+	timediff := (glfw.GetTime() - starfield.lastUpdatedTime) * 500
+	starfield.lastUpdatedTime = glfw.GetTime()
+	if paused || !ship.IsAlive() {
+		return
+	}
+
+	for _, layer := range starfield.Layers {
+		layer.offsetX = math.Mod(layer.offsetX-ship.VelocityX*layer.Parallax*timediff, screenWidth)
+		layer.offsetY = math.Mod(layer.offsetY-ship.VelocityY*layer.Parallax*timediff, fieldSize)
+	}
+}
+
+// Draws the stars behind the HUD, wrapped into the screen so the sky
+// stays put however the camera moves.
+func (starfield *Starfield) Draw() {
+	for _, layer := range starfield.Layers {
+		gl.PointSize(layer.PointSize)
+		gl.Begin(gl.POINTS)
+		gl.Color3d(Colorize(layer.Brightness), Colorize(layer.Brightness), Colorize(layer.Brightness))
+		for _, star := range layer.Stars {
+			x := math.Mod(star.X*screenWidth+layer.offsetX+screenWidth, screenWidth)
+			y := math.Mod(star.Y*fieldSize+layer.offsetY+fieldSize, fieldSize)
+			gl.Vertex2d(x, y)
+		}
+		gl.End()
+	}
+	gl.PointSize(1)
+}
@@ -0,0 +1,116 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+* License, v. 2.0. If a copy of the MPL was not distributed with this
+* file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package main
+
+import (
+	"math"
+
+	"github.com/go-gl/gl/v2.1/gl"
+)
+
+// Something homing weapons can chase: asteroids, saucers, the boss's
+// weak points and enemy ships.
+type Target interface {
+	IsAlive() bool
+	Position() (float64, float64)
+	Radius() float64
+}
+
+// Everything player owner may aim at.
+func targetsFor(owner int) []Target {
+	var targets []Target
+	for _, asteroid := range asteroids {
+		if asteroid.IsAlive() {
+			targets = append(targets, asteroid)
+		}
+	}
+	for _, saucer := range saucers {
+		if saucer.IsAlive() {
+			targets = append(targets, saucer)
+		}
+	}
+	if boss != nil && boss.IsAlive() {
+		for _, point := range boss.WeakPoints {
+			if point.IsAlive() {
+				targets = append(targets, point)
+			}
+		}
+	}
+	for id, ships := range shipMap {
+		if id != owner && ships.IsAlive() && canHurt(owner, id) {
+			targets = append(targets, ships)
+		}
+	}
+	return targets
+}
+
+// Target of player owner closest to x, y, or nil if there is none.
+func nearestTarget(x, y float64, owner int) Target {
+	var nearest Target
+	best := math.Inf(1)
+	for _, target := range targetsFor(owner) {
+		tx, ty := target.Position()
+		if d := wrappedDistance(x, y, tx, ty); d < best {
+			nearest, best = target, d
+		}
+	}
+	return nearest
+}
+
+// Locks onto the target the ship points at most directly, preferring
+// near ones. Locking the same target again releases the lock.
+func (ship *Ship) LockTarget() {
+	if !ship.IsAlive() {
+		return
+	}
+	var best Target
+	bestScore := math.Inf(1)
+	for _, target := range targetsFor(ship.PlayerId) {
+		tx, ty := target.Position()
+		off := math.Abs(angleDiff(headingTo(ship.PosX, ship.PosY, tx, ty), ship.Angle))
+		if off > 45 {
+			continue
+		}
+		score := off + wrappedDistance(ship.PosX, ship.PosY, tx, ty)/10
+		if score < bestScore {
+			best, bestScore = target, score
+		}
+	}
+
+	if best == ship.LockedTarget {
+		best = nil
+	}
+	ship.LockedTarget = best
+}
+
+// Target the ship has locked onto, nil if none or if it is gone.
+func (ship *Ship) Locked() Target {
+	if ship.LockedTarget != nil && !ship.LockedTarget.IsAlive() {
+		ship.LockedTarget = nil
+	}
+	return ship.LockedTarget
+}
+
+// Draws brackets around the local ship's locked target.
+func drawReticle() {
+	target := ship.Locked()
+	if target == nil || !ship.IsAlive() {
+		return
+	}
+	x, y := target.Position()
+	r := target.Radius() + 4
+	c := shipColor(PlayerId)
+
+	gl.Begin(gl.LINES)
+	gl.Color3d(Colorize(c.R), Colorize(c.G), Colorize(c.B))
+	for _, corner := range []Vector{Vector{-1, -1}, Vector{-1, 1}, Vector{1, 1}, Vector{1, -1}} {
+		cx, cy := x+corner.X*r, y+corner.Y*r
+		gl.Vertex2d(cx, cy)
+		gl.Vertex2d(cx-corner.X*r/2, cy)
+		gl.Vertex2d(cx, cy)
+		gl.Vertex2d(cx, cy-corner.Y*r/2)
+	}
+	gl.End()
+}
@@ -0,0 +1,107 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+* License, v. 2.0. If a copy of the MPL was not distributed with this
+* file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package main
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+type Team struct {
+	Name  string
+	Color Color
+}
+
+var teams = []Team{
+	Team{"red", Color{1.0, 0.0, 0.0}},
+	Team{"blue", Color{0.0, 0.0, 1.0}},
+	Team{"green", Color{0.0, 1.0, 0.0}},
+	Team{"yellow", Color{1.0, 1.0, 0.0}},
+}
+
+// Team of player id, or -1 if the mode has no teams.
+func teamOf(id int) int {
+	if mode.Teams == 0 {
+		return -1
+	}
+	info, ok := players[id]
+	if !ok {
+		return -1
+	}
+	return info.Team
+}
+
+// Picks a team for a joining player: the preferred one if it is
+// valid, otherwise the team with the fewest players.
+func pickTeam(preferred int, infos map[int]PlayerInfo) int {
+	if mode.Teams == 0 {
+		return -1
+	}
+	if preferred >= 0 && preferred < mode.Teams {
+		return preferred
+	}
+	sizes := make([]int, mode.Teams)
+	for _, info := range infos {
+		if info.Team >= 0 && info.Team < mode.Teams {
+			sizes[info.Team] += 1
+		}
+	}
+	team := 0
+	for t := range sizes {
+		if sizes[t] < sizes[team] {
+			team = t
+		}
+	}
+	return team
+}
+
+// Tip color for the ship of player id: its team color in team
+// games, otherwise a color of its own.
+func shipColor(id int) Color {
+	if team := teamOf(id); team >= 0 {
+		return teams[team].Color
+	}
+	switch id {
+	case 0:
+		return Color{1.0, 0.0, 0.0}
+	case 1:
+		return Color{0.0, 1.0, 0.0}
+	case 2:
+		return Color{0.0, 0.0, 1.0}
+	}
+	// Seed with the id, so every node picks the same color.
+	r := rand.New(rand.NewSource(int64(id)))
+	return Color{r.Float64(), r.Float64(), r.Float64()}
+}
+
+// Sum of the frags of all players on team.
+func teamScore(team int) int {
+	total := 0
+	for _, id := range gameNode.PlayerIds() {
+		if teamOf(id) == team {
+			total += fragCount(id)
+		}
+	}
+	return total
+}
+
+// Team with the most frags.
+func leadingTeam() int {
+	best := 0
+	for t := 1; t < mode.Teams; t++ {
+		if teamScore(t) > teamScore(best) {
+			best = t
+		}
+	}
+	return best
+}
+
+func drawTeamScores(y float64) float64 {
+	for t := 0; t < mode.Teams; t++ {
+		DrawString(screenWidth-110, y, 1, teams[t].Color, fmt.Sprintf("%s: %d", teams[t].Name, teamScore(t)))
+		y -= 12
+	}
+	return y
+}
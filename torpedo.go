@@ -4,14 +4,24 @@
 
 package main
 
-import glfw "github.com/go-gl/glfw3/v3.0/glfw"
+import (
+	"math"
 
+	glfw "github.com/go-gl/glfw3/v3.0/glfw"
+)
+
+// Guided torpedo. It chases its target, or the target nearest to it
+// if it has none, turning at most SteerRate degrees per step, and
+// blows up into a big explosion on contact or when it runs out.
 type Torpedo struct {
 	Entity
 	MaxLifetime float64
+	Owner       int
+	Target      Target
+	SteerRate   float64
 }
 
-func NewTorpedo(x, y, angle, vX, vY float64) *Torpedo {
+func NewTorpedo(x, y, angle, vX, vY float64, owner int, target Target) *Torpedo {
 	shape := Polygon{
 		[]Vector{
 			Vector{0, 1},
@@ -24,17 +34,40 @@ func NewTorpedo(x, y, angle, vX, vY float64) *Torpedo {
 			Color{1, 0, 1},
 		},
 	}
-	return &Torpedo{*NewEntity(shape, x, y, angle, 0, vX, vY, 0, 5), 1.5}
+	return &Torpedo{*NewEntity(shape, x, y, angle, 0, vX, vY, 0, 5), 2, owner, target, 0.3}
 }
 
 func (torpedo *Torpedo) Update() {
 	if paused {
 		timediff := (glfw.GetTime() - torpedo.Entity.lastUpdatedTime)
 		torpedo.MaxLifetime = torpedo.MaxLifetime + timediff
+	} else {
+		torpedo.steer((glfw.GetTime() - torpedo.Entity.lastUpdatedTime) * 500)
 	}
 	torpedo.Entity.Update()
 }
 
+// Turns the torpedo towards its target, keeping its speed.
+func (torpedo *Torpedo) steer(timediff float64) {
+	if torpedo.Target == nil || !torpedo.Target.IsAlive() {
+		torpedo.Target = nearestTarget(torpedo.PosX, torpedo.PosY, torpedo.Owner)
+	}
+	if torpedo.Target == nil {
+		return
+	}
+
+	x, y := torpedo.Target.Position()
+	turn := angleDiff(headingTo(torpedo.PosX, torpedo.PosY, x, y), torpedo.Angle)
+	maxTurn := torpedo.SteerRate * timediff
+	turn = math.Max(-maxTurn, math.Min(maxTurn, turn))
+	torpedo.Angle += turn
+
+	var rad float64 = ((torpedo.Angle) * math.Pi) / 180
+	speed := math.Hypot(torpedo.VelocityX, torpedo.VelocityY)
+	torpedo.VelocityX = speed * math.Sin(rad)
+	torpedo.VelocityY = speed * math.Cos(rad)
+}
+
 func (torpedo *Torpedo) IsAlive() bool {
 	if glfw.GetTime() > torpedo.createdTime+torpedo.MaxLifetime {
 		torpedo.Destroy()
@@ -43,7 +76,51 @@ func (torpedo *Torpedo) IsAlive() bool {
 }
 
 func (torpedo *Torpedo) Destroy() {
+	if !torpedo.Entity.IsAlive() {
+		return
+	}
 	torpedo.Entity.Destroy()
 	explosions = append(explosions, NewExplosion(torpedo.PosX, torpedo.PosY, 10))
-	bigExplosions = append(bigExplosions, NewBigExplosion(torpedo.PosX, torpedo.PosY, 2))
+	bigExplosions = append(bigExplosions, NewBigExplosion(torpedo.PosX, torpedo.PosY, 2, torpedo.Owner))
+}
+
+// Fires guided torpedos at the ship's locked target.
+type TorpedoLauncher struct {
+	Magazine
+}
+
+func init() {
+	RegisterWeapon(1, func() Weapon { return &TorpedoLauncher{NewMagazine(1, 1)} })
+}
+
+func (launcher *TorpedoLauncher) Name() string {
+	return "torpedo"
+}
+
+func (launcher *TorpedoLauncher) Cooldown(ship *Ship) float64 {
+	return 0.5
+}
+
+func (launcher *TorpedoLauncher) Spread(ship *Ship) []float64 {
+	return []float64{0}
+}
+
+func (launcher *TorpedoLauncher) Launch(ship *Ship, angle float64) {
+	var rad float64 = ((angle) * math.Pi) / 180
+	x, y := RotateVector(&Vector{0, 8}, angle)
+
+	torpedo := NewTorpedo(
+		ship.PosX+x,
+		ship.PosY+y,
+		angle,
+		ship.MaxVelocity*math.Sin(rad)*1.5,
+		ship.MaxVelocity*math.Cos(rad)*1.5,
+		ship.PlayerId,
+		ship.Locked(),
+	)
+	torpedos = append(torpedos, torpedo)
+}
+
+func (launcher *TorpedoLauncher) Automatic() bool {
+	return false
 }
@@ -52,10 +52,58 @@ func (v *Vector) Rotate(angle float64) (float64, float64) {
 	return x, y
 }
 
+// How many copies of the field to check on each side for things
+// reaching across the boundary: one, or none in a bounded arena.
+func wrapCopies() float64 {
+	if mode.Arena {
+		return 0
+	}
+	return 1
+}
+
+// Offset from x1, y1 to x2, y2, taking the shortest way across the
+// field's wrap-around.
+func wrappedOffset(x1, y1, x2, y2 float64) (float64, float64) {
+	dx := x2 - x1
+	if mode.Arena {
+		return dx, y2 - y1
+	}
+	if dx > gameWidth/2 {
+		dx -= gameWidth
+	} else if dx < -gameWidth/2 {
+		dx += gameWidth
+	}
+	dy := y2 - y1
+	if dy > gameHeight/2 {
+		dy -= gameHeight
+	} else if dy < -gameHeight/2 {
+		dy += gameHeight
+	}
+	return dx, dy
+}
+
+// Shortest distance between two points, measured across the
+// field's wrap-around.
+func wrappedDistance(x1, y1, x2, y2 float64) float64 {
+	return math.Hypot(wrappedOffset(x1, y1, x2, y2))
+}
+
+// Direction from x1, y1 to x2, y2 in degrees, 0 pointing up like
+// an Entity's Angle.
+func headingTo(x1, y1, x2, y2 float64) float64 {
+	dx, dy := wrappedOffset(x1, y1, x2, y2)
+	return math.Atan2(dx, dy) * 180 / math.Pi
+}
+
+// Difference between two angles in degrees, in [-180, 180).
+func angleDiff(a, b float64) float64 {
+	return math.Mod(math.Mod(a-b+180, 360)+360, 360) - 180
+}
+
 func IsColliding(a *Entity, b *Entity) bool {
 	// check everything 9 times in a 3x3 grid for collision detection across boundaries
-	for x := -1.0; x < 2.0; x++ {
-		for y := -1.0; y < 2.0; y++ {
+	for x := -wrapCopies(); x <= wrapCopies(); x++ {
+		for y := -wrapCopies(); y <= wrapCopies(); y++ {
 			var mod Entity = *a
 			mod.PosX = mod.PosX + (gameWidth * x)
 			mod.PosY = mod.PosY + (gameHeight * y)
@@ -74,6 +122,44 @@ func IsColliding(a *Entity, b *Entity) bool {
 	return false
 }
 
+// Casts a ray of the given length from x, y in direction angle and
+// returns how far along it the first edge of ent's polygon lies.
+// Like IsColliding it checks a 3x3 grid, so a ray leaving the field
+// hits polygons on the other side of the boundary.
+func RayIntersection(x, y, angle, length float64, ent *Entity) (float64, bool) {
+	var rad float64 = ((angle) * math.Pi) / 180
+	dx, dy := math.Sin(rad), math.Cos(rad)
+
+	nearest, hit := length, false
+	for gx := -wrapCopies(); gx <= wrapCopies(); gx++ {
+		for gy := -wrapCopies(); gy <= wrapCopies(); gy++ {
+			offsetX := ent.PosX + (gameWidth * gx)
+			offsetY := ent.PosY + (gameHeight * gy)
+
+			n := len(ent.Shape.Vectors)
+			for v := 0; v < n; v++ {
+				ax, ay := ent.Shape.Vectors[v].Rotate(ent.Angle)
+				bx, by := ent.Shape.Vectors[(v+1)%n].Rotate(ent.Angle)
+				ax, ay = ax+offsetX, ay+offsetY
+				ex, ey := bx+offsetX-ax, by+offsetY-ay
+
+				// solve x + t*d = a + u*e for the distance t along the ray
+				denom := dx*ey - dy*ex
+				if math.Abs(denom) < 1e-9 {
+					continue
+				}
+				t := ((ax-x)*ey - (ay-y)*ex) / denom
+				u := ((ax-x)*dy - (ay-y)*dx) / denom
+				if t >= 0 && t <= nearest && u >= 0 && u <= 1 {
+					nearest, hit = t, true
+				}
+			}
+		}
+	}
+
+	return nearest, hit
+}
+
 func getGeometry(ent *Entity) *geos.Geometry {
 	var shell []geos.Coord
 	for v, _ := range ent.Shape.Vectors {
@@ -192,6 +278,23 @@ func drawCharacter(x, y, size float64, color Color, char string) {
 		c.glVertex2d(4, 0)
 		c.glVertex2d(0, 4)
 		c.glVertex2d(4, 4)
+	case "B":
+		c.glVertex2d(0, 0)
+		c.glVertex2d(0, 8)
+		c.glVertex2d(0, 8)
+		c.glVertex2d(3, 8)
+		c.glVertex2d(3, 8)
+		c.glVertex2d(4, 6)
+		c.glVertex2d(4, 6)
+		c.glVertex2d(3, 4)
+		c.glVertex2d(0, 4)
+		c.glVertex2d(3, 4)
+		c.glVertex2d(3, 4)
+		c.glVertex2d(4, 2)
+		c.glVertex2d(4, 2)
+		c.glVertex2d(3, 0)
+		c.glVertex2d(3, 0)
+		c.glVertex2d(0, 0)
 	case "C":
 		c.glVertex2d(4, 8)
 		c.glVertex2d(0, 8)
@@ -249,6 +352,20 @@ func drawCharacter(x, y, size float64, color Color, char string) {
 	case "I":
 		c.glVertex2d(2, 0)
 		c.glVertex2d(2, 8)
+	case "J":
+		c.glVertex2d(4, 8)
+		c.glVertex2d(4, 0)
+		c.glVertex2d(4, 0)
+		c.glVertex2d(0, 0)
+		c.glVertex2d(0, 0)
+		c.glVertex2d(0, 2)
+	case "K":
+		c.glVertex2d(0, 0)
+		c.glVertex2d(0, 8)
+		c.glVertex2d(0, 4)
+		c.glVertex2d(4, 8)
+		c.glVertex2d(0, 4)
+		c.glVertex2d(4, 0)
 	case "L":
 		c.glVertex2d(0, 8)
 		c.glVertex2d(0, 0)
@@ -288,6 +405,17 @@ func drawCharacter(x, y, size float64, color Color, char string) {
 		c.glVertex2d(4, 4)
 		c.glVertex2d(4, 4)
 		c.glVertex2d(0, 4)
+	case "Q":
+		c.glVertex2d(0, 0)
+		c.glVertex2d(0, 8)
+		c.glVertex2d(0, 8)
+		c.glVertex2d(4, 8)
+		c.glVertex2d(4, 8)
+		c.glVertex2d(4, 0)
+		c.glVertex2d(4, 0)
+		c.glVertex2d(0, 0)
+		c.glVertex2d(2, 2)
+		c.glVertex2d(5, -1)
 	case "R":
 		c.glVertex2d(0, 0)
 		c.glVertex2d(0, 8)
@@ -348,6 +476,13 @@ func drawCharacter(x, y, size float64, color Color, char string) {
 		c.glVertex2d(4, 8)
 		c.glVertex2d(2, 4)
 		c.glVertex2d(2, 0)
+	case "Z":
+		c.glVertex2d(0, 8)
+		c.glVertex2d(4, 8)
+		c.glVertex2d(4, 8)
+		c.glVertex2d(0, 0)
+		c.glVertex2d(0, 0)
+		c.glVertex2d(4, 0)
 	case " ":
 	case "-":
 		c.glVertex2d(1, 4)
@@ -362,6 +497,12 @@ func drawCharacter(x, y, size float64, color Color, char string) {
 		c.glVertex2d(1, 1)
 		c.glVertex2d(1, 3)
 		c.glVertex2d(1, 8)
+	case ".":
+		c.glVertex2d(1, 0)
+		c.glVertex2d(1, 1)
+	case "_":
+		c.glVertex2d(0, 0)
+		c.glVertex2d(4, 0)
 
 	}
 
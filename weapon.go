@@ -0,0 +1,190 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+* License, v. 2.0. If a copy of the MPL was not distributed with this
+* file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	glfw "github.com/go-gl/glfw3/v3.0/glfw"
+)
+
+// Something a ship can fire. Each weapon lives in its own file next
+// to its projectile and adds itself to the inventory with
+// RegisterWeapon from an init function, and its projectiles to the
+// game loop with RegisterProjectiles.
+type Weapon interface {
+	Name() string
+	// Seconds between two shots of ship.
+	Cooldown(ship *Ship) float64
+	// Directions of the projectiles of one shot, relative to the
+	// ship's nose.
+	Spread(ship *Ship) []float64
+	// Launches one projectile from ship in direction angle.
+	Launch(ship *Ship, angle float64)
+	// Whether holding the trigger keeps firing.
+	Automatic() bool
+	// Shots left, -1 for unlimited.
+	Ammo() int
+	UseAmmo()
+	// Tops the ammo up, as an ammo power-up does.
+	Refill()
+}
+
+// Ammo of a weapon with a limited supply. Weapons embed it to get
+// the ammo part of Weapon.
+type Magazine struct {
+	rounds int
+	refill int // Rounds added by Refill, 0 for unlimited ammo.
+}
+
+func NewMagazine(rounds, refill int) Magazine {
+	return Magazine{rounds, refill}
+}
+
+func (magazine *Magazine) Ammo() int {
+	if magazine.refill == 0 {
+		return -1
+	}
+	return magazine.rounds
+}
+
+func (magazine *Magazine) UseAmmo() {
+	if magazine.refill > 0 && magazine.rounds > 0 {
+		magazine.rounds -= 1
+	}
+}
+
+func (magazine *Magazine) Refill() {
+	magazine.rounds += magazine.refill
+}
+
+// Weapons that heat up instead of running out of ammo; the HUD shows
+// their heat.
+type heater interface {
+	// 0 when cold, 1 when overheated.
+	Heat() float64
+}
+
+// What a weapon leaves flying around the field. Weapons register
+// theirs with RegisterProjectiles from the same init function, so the
+// game loop moves, draws and clears them without knowing the weapon.
+type Projectiles interface {
+	// Drops the ones that are gone and moves the rest.
+	Update()
+	Draw()
+	// Clears them all for a new game.
+	Reset()
+}
+
+var projectileKinds []Projectiles
+
+func RegisterProjectiles(projectiles Projectiles) {
+	projectileKinds = append(projectileKinds, projectiles)
+}
+
+func updateProjectiles() {
+	for _, projectiles := range projectileKinds {
+		projectiles.Update()
+	}
+}
+
+func drawProjectiles() {
+	for _, projectiles := range projectileKinds {
+		projectiles.Draw()
+	}
+}
+
+func resetProjectiles() {
+	for _, projectiles := range projectileKinds {
+		projectiles.Reset()
+	}
+}
+
+type weaponSlot struct {
+	order   int
+	factory func() Weapon
+}
+
+var weaponSlots []weaponSlot
+
+// Adds a weapon to the inventory of every new ship, ordered by order.
+func RegisterWeapon(order int, factory func() Weapon) {
+	weaponSlots = append(weaponSlots, weaponSlot{order, factory})
+}
+
+type byOrder []weaponSlot
+
+func (slots byOrder) Len() int           { return len(slots) }
+func (slots byOrder) Swap(i, j int)      { slots[i], slots[j] = slots[j], slots[i] }
+func (slots byOrder) Less(i, j int) bool { return slots[i].order < slots[j].order }
+
+// A fresh set of every registered weapon.
+func NewInventory() []Weapon {
+	sort.Sort(byOrder(weaponSlots))
+	var weapons []Weapon
+	for _, slot := range weaponSlots {
+		weapons = append(weapons, slot.factory())
+	}
+	return weapons
+}
+
+func (ship *Ship) SelectedWeapon() Weapon {
+	return ship.Weapons[ship.selected]
+}
+
+func (ship *Ship) NextWeapon() {
+	ship.selected = (ship.selected + 1) % len(ship.Weapons)
+}
+
+func (ship *Ship) PreviousWeapon() {
+	ship.selected = (ship.selected + len(ship.Weapons) - 1) % len(ship.Weapons)
+}
+
+// Weapon with the given name, or nil if the ship has none.
+func (ship *Ship) Weapon(name string) Weapon {
+	for _, weapon := range ship.Weapons {
+		if weapon.Name() == name {
+			return weapon
+		}
+	}
+	return nil
+}
+
+// Fires one shot of weapon if it has cooled down and has ammo left.
+func (ship *Ship) Fire(weapon Weapon) {
+	if weapon == nil || !ship.IsAlive() || weapon.Ammo() == 0 {
+		return
+	}
+	if glfw.GetTime() < ship.lastFired[weapon.Name()]+weapon.Cooldown(ship) {
+		return
+	}
+	for _, offset := range weapon.Spread(ship) {
+		weapon.Launch(ship, ship.Angle+offset)
+	}
+	weapon.UseAmmo()
+	ship.lastFired[weapon.Name()] = glfw.GetTime()
+}
+
+// Fires the weapon with the given name, regardless of the selection.
+func (ship *Ship) FireWeapon(name string) {
+	ship.Fire(ship.Weapon(name))
+}
+
+// Shows the selected weapon and its ammo or heat below the shield bar.
+func drawWeapon() {
+	if !ship.IsAlive() {
+		return
+	}
+	weapon := ship.SelectedWeapon()
+	text := weapon.Name()
+	if ammo := weapon.Ammo(); ammo >= 0 {
+		text = fmt.Sprintf("%s: %d", text, ammo)
+	}
+	DrawString(10, fieldSize-74, 1, Color{0.7, 0.7, 0.7}, text)
+	if h, ok := weapon.(heater); ok {
+		drawBar(10, fieldSize-82, 60, h.Heat(), Color{1, 0.4, 0.2})
+	}
+}